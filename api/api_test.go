@@ -1,316 +1,1007 @@
-package api
-
-import (
-	"bytes"
-	"fmt"
-	"strings"
-	"testing"
-)
-
-func TestToUnicode(t *testing.T) {
-	data := []struct {
-		in, out string
-	}{
-		{"{\\o}", "ø"},
-		{"{\\O}", "Ø"},
-		{"{\\`A}", "À"},
-		{"{\\'A}", "Á"},
-		{"{\\^A}", "Â"},
-		{"{\\~A}", "Ã"},
-		{"{\\\"A}", "Ä"},
-		{"{\\AA}", "Å"},
-		{"{\\AE}", "Æ"},
-		{"{\\c{C}}", "Ç"},
-		{"{\\c C}", "Ç"},
-		{"{\\`E}", "È"},
-		{"{\\'E}", "É"},
-		{"{\\^E}", "Ê"},
-		{"{\\\"E}", "Ë"},
-		{"{\\`I}", "Ì"},
-		{"{\\'I}", "Í"},
-		{"{\\^I}", "Î"},
-		{"{\\\"I}", "Ï"},
-		{"{\\~N}", "Ñ"},
-		{"{\\`O}", "Ò"},
-		{"{\\'O}", "Ó"},
-		{"{\\^O}", "Ô"},
-		{"{\\~O}", "Õ"},
-		{"{\\\"O}", "Ö"},
-		{"{\\OE}", "Œ"},
-		{"{\\`U}", "Ù"},
-		{"{\\'U}", "Ú"},
-		{"{\\^U}", "Û"},
-		{"{\\\"U}", "Ü"},
-		{"{\\'Y}", "Ý"},
-		{"{\\\"Y}", "Ÿ"},
-		{"{\\ss}", "ß"},
-		{"{\\`a}", "à"},
-		{"{\\'a}", "á"},
-		{"{\\^a}", "â"},
-		{"{\\~a}", "ã"},
-		{"{\\\"a}", "ä"},
-		{"{\\aa}", "å"},
-		{"{\\ae}", "æ"},
-		{"{\\c{c}}", "ç"},
-		{"{\\c c}", "ç"},
-		{"{\\`e}", "è"},
-		{"{\\'e}", "é"},
-		{"{\\^e}", "ê"},
-		{"{\\\"e}", "ë"},
-		{"{\\`\\i}", "ı̀"},
-		{"{\\'\\i}", "ı́"},
-		{"{\\^\\i}", "ı̂"},
-		{"{\\\"\\i}", "ı̈"},
-		{"{\\~n}", "ñ"},
-		{"{\\`o}", "ò"},
-		{"{\\'o}", "ó"},
-		{"{\\^o}", "ô"},
-		{"{\\~o}", "õ"},
-		{"{\\\"o}", "ö"},
-		{"{\\oe}", "œ"},
-		{"{\\`u}", "ù"},
-		{"{\\'u}", "ú"},
-		{"{\\^u}", "û"},
-		{"{\\\"u}", "ü"},
-		{"{\\'y}", "ý"},
-		{"{\\\"y}", "ÿ"},
-		{"\\`{A}", "À"},
-		{"\\'{A}", "Á"},
-		{"\\^{A}", "Â"},
-		{"\\~{A}", "Ã"},
-		{"\\\"{A}", "Ä"},
-		{"\\k{A}", "Ą"},
-		{"\\AE{}", "Æ"},
-		{"\\AE ", "Æ"},
-		{"\\`{E}", "È"},
-		{"\\'{E}", "É"},
-		{"\\^{E}", "Ê"},
-		{"\\\"{E}", "Ë"},
-		{"\\k{E}", "Ę"},
-		{"\\c{C}", "Ç"},
-		{"\\c C", "Ç"},
-		{"\\`{I}", "Ì"},
-		{"\\'{I}", "Í"},
-		{"\\^{I}", "Î"},
-		{"\\\"{I}", "Ï"},
-		{"\\k{I}", "Į"},
-		{"\\~{N}", "Ñ"},
-		{"\\`{O}", "Ò"},
-		{"\\'{O}", "Ó"},
-		{"\\^{O}", "Ô"},
-		{"\\~{O}", "Õ"},
-		{"\\\"{O}", "Ö"},
-		{"\\H{O}", "Ő"},
-		{"\\OE{}", "Œ"},
-		{"\\OE ", "Œ"},
-		{"\\`{U}", "Ù"},
-		{"\\'{U}", "Ú"},
-		{"\\^{U}", "Û"},
-		{"\\\"{U}", "Ü"},
-		{"\\H{U}", "Ű"},
-		{"\\k{U}", "Ų"},
-		{"\\'{Y}", "Ý"},
-		{"\\\"{Y}", "Ÿ"},
-		{"\\`{a}", "à"},
-		{"\\'{a}", "á"},
-		{"\\^{a}", "â"},
-		{"\\~{a}", "ã"},
-		{"\\\"{a}", "ä"},
-		{"\\k{a}", "ą"},
-		{"\\ae{}", "æ"},
-		{"\\ae ", "æ"},
-		{"\\c{c}", "ç"},
-		{"\\c c", "ç"},
-		{"\\`{e}", "è"},
-		{"\\'{e}", "é"},
-		{"\\^{e}", "ê"},
-		{"\\\"{e}", "ë"},
-		{"\\k{e}", "ę"},
-		{"\\`{\\i}", "ı̀"},
-		{"\\'{\\i}", "ı́"},
-		{"\\^{\\i}", "ı̂"},
-		{"\\\"{\\i}", "ı̈"},
-		{"\\k{i}", "į"},
-		{"\\~{n}", "ñ"},
-		{"\\`{o}", "ò"},
-		{"\\'{o}", "ó"},
-		{"\\^{o}", "ô"},
-		{"\\~{o}", "õ"},
-		{"\\\"{o}", "ö"},
-		{"\\H{o}", "ő"},
-		{"\\oe{}", "œ"},
-		{"\\oe ", "œ"},
-		{"\\`{u}", "ù"},
-		{"\\'{u}", "ú"},
-		{"\\^{u}", "û"},
-		{"\\\"{u}", "ü"},
-		{"\\H{u}", "ű"},
-		{"\\k{u}", "ų"},
-		{"\\'{y}", "ý"},
-		{"\\\"{y}", "ÿ"},
-		{"\\`A", "À"},
-		{"\\'A", "Á"},
-		{"\\^A", "Â"},
-		{"\\~A", "Ã"},
-		{"\\\"A", "Ä"},
-		{"\\`E", "È"},
-		{"\\'E", "É"},
-		{"\\^E", "Ê"},
-		{"\\\"E", "Ë"},
-		{"\\`I", "Ì"},
-		{"\\'I", "Í"},
-		{"\\^I", "Î"},
-		{"\\\"I", "Ï"},
-		{"\\~N", "Ñ"},
-		{"\\`O", "Ò"},
-		{"\\'O", "Ó"},
-		{"\\^O", "Ô"},
-		{"\\~O", "Õ"},
-		{"\\\"O", "Ö"},
-		{"\\`U", "Ù"},
-		{"\\'U", "Ú"},
-		{"\\^U", "Û"},
-		{"\\\"U", "Ü"},
-		{"\\'Y", "Ý"},
-		{"\\\"Y", "Ÿ"},
-		{"\\`a", "à"},
-		{"\\'a", "á"},
-		{"\\^a", "â"},
-		{"\\~a", "ã"},
-		{"\\\"a", "ä"},
-		{"\\`e", "è"},
-		{"\\'e", "é"},
-		{"\\^e", "ê"},
-		{"\\\"e", "ë"},
-		{"\\`\\i", "ı̀"},
-		{"\\'\\i", "ı́"},
-		{"\\^\\i", "ı̂"},
-		{"\\\"\\i", "ı̈"},
-		{"\\~n", "ñ"},
-		{"\\`o", "ò"},
-		{"\\'o", "ó"},
-		{"\\^o", "ô"},
-		{"\\~o", "õ"},
-		{"\\\"o", "ö"},
-		{"\\`u", "ù"},
-		{"\\'u", "ú"},
-		{"\\^u", "û"},
-		{"\\\"u", "ü"},
-		{"\\'y", "ý"},
-		{"\\\"y", "ÿ"},
-	}
-
-	var out bytes.Buffer // the output writer
-	for i, d := range data {
-		out.Reset()
-		in := strings.NewReader(d.in)
-		if got := ToUnicode(&out, in); got != nil {
-			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.in, got)
-		}
-		if out.String() != d.out {
-			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.in, out.String(), d.out)
-		}
-	}
-}
-
-func TestToLaTeX(t *testing.T) {
-	data := []struct {
-		in, out string
-	}{
-		{"ø", "{\\o}"},
-		{"Ø", "{\\O}"},
-		{"À", "\\`A"},
-		{"Á", "\\'A"},
-		{"Â", "\\^A"},
-		{"Ã", "\\~A"},
-		{"Ä", "\\\"A"},
-		{"Å", "{\\AA}"},
-		{"Æ", "{\\AE}"},
-		{"Ą", "\\k{A}"},
-		{"Ç", "\\c{C}"},
-		{"È", "\\`E"},
-		{"É", "\\'E"},
-		{"Ê", "\\^E"},
-		{"Ë", "\\\"E"},
-		{"Ę", "\\k{E}"},
-		{"Ì", "\\`I"},
-		{"Í", "\\'I"},
-		{"Î", "\\^I"},
-		{"Ï", "\\\"I"},
-		{"Į", "\\k{I}"},
-		{"Ñ", "\\~N"},
-		{"Ò", "\\`O"},
-		{"Ó", "\\'O"},
-		{"Ô", "\\^O"},
-		{"Õ", "\\~O"},
-		{"Ö", "\\\"O"},
-		{"Ő", "\\H{O}"},
-		{"Œ", "{\\OE}"},
-		{"Ù", "\\`U"},
-		{"Ú", "\\'U"},
-		{"Û", "\\^U"},
-		{"Ü", "\\\"U"},
-		{"Ű", "\\H{U}"},
-		{"Ų", "\\k{U}"},
-		{"Ý", "\\'Y"},
-		{"Ÿ", "\\\"Y"},
-		{"ß", "{\\ss}"},
-		{"à", "\\`a"},
-		{"á", "\\'a"},
-		{"â", "\\^a"},
-		{"ã", "\\~a"},
-		{"ä", "\\\"a"},
-		{"å", "{\\aa}"},
-		{"ą", "\\k{a}"},
-		{"æ", "{\\ae}"},
-		{"ç", "\\c{c}"},
-		{"è", "\\`e"},
-		{"é", "\\'e"},
-		{"ê", "\\^e"},
-		{"ë", "\\\"e"},
-		{"ę", "\\k{e}"},
-		{"ì", "\\`{\\i}"},
-		{"í", "\\'{\\i}"},
-		{"î", "\\^{\\i}"},
-		{"ï", "\\\"{\\i}"},
-		{"į", "\\k{i}"},
-		{"ñ", "\\~n"},
-		{"ò", "\\`o"},
-		{"ó", "\\'o"},
-		{"ô", "\\^o"},
-		{"õ", "\\~o"},
-		{"ö", "\\\"o"},
-		{"ő", "\\H{o}"},
-		{"œ", "{\\oe}"},
-		{"ù", "\\`u"},
-		{"ú", "\\'u"},
-		{"û", "\\^u"},
-		{"ü", "\\\"u"},
-		{"ű", "\\H{u}"},
-		{"ų", "\\k{u}"},
-		{"ý", "\\'y"},
-		{"ÿ", "\\\"y"},
-	}
-	context := []string{
-		"%s",
-		"before %s",
-		"%s after",
-		"before %s after",
-	}
-
-	var out bytes.Buffer // the output writer
-	for i, d := range data {
-		for j, c := range context {
-			out.Reset()
-			inc := fmt.Sprintf(c, d.in)
-			in := strings.NewReader(inc)
-			if got := ToLaTeX(&out, in); got != nil {
-				t.Errorf("test %d.%d: ToLaTeX(%q) = %v, want nil", i, j, inc, got)
-			}
-			want := fmt.Sprintf(c, d.out)
-			if out.String() != want {
-				t.Errorf("test %d.%d: ToLaTeX(%q) = %q, want %q", i, j, inc, out.String(), want)
-			}
-		}
-	}
-}
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kpym/laxents/transformers"
+)
+
+func TestToUnicode(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"{\\o}", "ø"},
+		{"{\\O}", "Ø"},
+		{"{\\`A}", "À"},
+		{"{\\'A}", "Á"},
+		{"{\\^A}", "Â"},
+		{"{\\~A}", "Ã"},
+		{"{\\\"A}", "Ä"},
+		{"{\\AA}", "Å"},
+		{"{\\AE}", "Æ"},
+		{"{\\c{C}}", "Ç"},
+		{"{\\c C}", "Ç"},
+		{"{\\`E}", "È"},
+		{"{\\'E}", "É"},
+		{"{\\^E}", "Ê"},
+		{"{\\\"E}", "Ë"},
+		{"{\\`I}", "Ì"},
+		{"{\\'I}", "Í"},
+		{"{\\^I}", "Î"},
+		{"{\\\"I}", "Ï"},
+		{"{\\~N}", "Ñ"},
+		{"{\\`O}", "Ò"},
+		{"{\\'O}", "Ó"},
+		{"{\\^O}", "Ô"},
+		{"{\\~O}", "Õ"},
+		{"{\\\"O}", "Ö"},
+		{"{\\OE}", "Œ"},
+		{"{\\`U}", "Ù"},
+		{"{\\'U}", "Ú"},
+		{"{\\^U}", "Û"},
+		{"{\\\"U}", "Ü"},
+		{"{\\'Y}", "Ý"},
+		{"{\\\"Y}", "Ÿ"},
+		{"{\\ss}", "ß"},
+		{"{\\`a}", "à"},
+		{"{\\'a}", "á"},
+		{"{\\^a}", "â"},
+		{"{\\~a}", "ã"},
+		{"{\\\"a}", "ä"},
+		{"{\\aa}", "å"},
+		{"{\\ae}", "æ"},
+		{"{\\c{c}}", "ç"},
+		{"{\\c c}", "ç"},
+		{"{\\`e}", "è"},
+		{"{\\'e}", "é"},
+		{"{\\^e}", "ê"},
+		{"{\\\"e}", "ë"},
+		{"{\\`\\i}", "ì"},
+		{"{\\'\\i}", "í"},
+		{"{\\^\\i}", "î"},
+		{"{\\\"\\i}", "ï"},
+		{"{\\~n}", "ñ"},
+		{"{\\`o}", "ò"},
+		{"{\\'o}", "ó"},
+		{"{\\^o}", "ô"},
+		{"{\\~o}", "õ"},
+		{"{\\\"o}", "ö"},
+		{"{\\oe}", "œ"},
+		{"{\\`u}", "ù"},
+		{"{\\'u}", "ú"},
+		{"{\\^u}", "û"},
+		{"{\\\"u}", "ü"},
+		{"{\\'y}", "ý"},
+		{"{\\\"y}", "ÿ"},
+		{"\\`{A}", "À"},
+		{"\\'{A}", "Á"},
+		{"\\^{A}", "Â"},
+		{"\\~{A}", "Ã"},
+		{"\\\"{A}", "Ä"},
+		{"\\k{A}", "Ą"},
+		{"\\AE{}", "Æ"},
+		{"\\AE ", "Æ"},
+		{"\\`{E}", "È"},
+		{"\\'{E}", "É"},
+		{"\\^{E}", "Ê"},
+		{"\\\"{E}", "Ë"},
+		{"\\k{E}", "Ę"},
+		{"\\c{C}", "Ç"},
+		{"\\c C", "Ç"},
+		{"\\`{I}", "Ì"},
+		{"\\'{I}", "Í"},
+		{"\\^{I}", "Î"},
+		{"\\\"{I}", "Ï"},
+		{"\\k{I}", "Į"},
+		{"\\~{N}", "Ñ"},
+		{"\\`{O}", "Ò"},
+		{"\\'{O}", "Ó"},
+		{"\\^{O}", "Ô"},
+		{"\\~{O}", "Õ"},
+		{"\\\"{O}", "Ö"},
+		{"\\H{O}", "Ő"},
+		{"\\OE{}", "Œ"},
+		{"\\OE ", "Œ"},
+		{"\\`{U}", "Ù"},
+		{"\\'{U}", "Ú"},
+		{"\\^{U}", "Û"},
+		{"\\\"{U}", "Ü"},
+		{"\\H{U}", "Ű"},
+		{"\\k{U}", "Ų"},
+		{"\\'{Y}", "Ý"},
+		{"\\\"{Y}", "Ÿ"},
+		{"\\`{a}", "à"},
+		{"\\'{a}", "á"},
+		{"\\^{a}", "â"},
+		{"\\~{a}", "ã"},
+		{"\\\"{a}", "ä"},
+		{"\\k{a}", "ą"},
+		{"\\ae{}", "æ"},
+		{"\\ae ", "æ"},
+		{"\\c{c}", "ç"},
+		{"\\c c", "ç"},
+		{"\\`{e}", "è"},
+		{"\\'{e}", "é"},
+		{"\\^{e}", "ê"},
+		{"\\\"{e}", "ë"},
+		{"\\k{e}", "ę"},
+		{"\\`{\\i}", "ì"},
+		{"\\'{\\i}", "í"},
+		{"\\^{\\i}", "î"},
+		{"\\\"{\\i}", "ï"},
+		{"\\k{i}", "į"},
+		{"\\~{n}", "ñ"},
+		{"\\`{o}", "ò"},
+		{"\\'{o}", "ó"},
+		{"\\^{o}", "ô"},
+		{"\\~{o}", "õ"},
+		{"\\\"{o}", "ö"},
+		{"\\H{o}", "ő"},
+		{"\\oe{}", "œ"},
+		{"\\oe ", "œ"},
+		{"\\`{u}", "ù"},
+		{"\\'{u}", "ú"},
+		{"\\^{u}", "û"},
+		{"\\\"{u}", "ü"},
+		{"\\H{u}", "ű"},
+		{"\\k{u}", "ų"},
+		{"\\'{y}", "ý"},
+		{"\\\"{y}", "ÿ"},
+		{"\\`A", "À"},
+		{"\\'A", "Á"},
+		{"\\^A", "Â"},
+		{"\\~A", "Ã"},
+		{"\\\"A", "Ä"},
+		{"\\`E", "È"},
+		{"\\'E", "É"},
+		{"\\^E", "Ê"},
+		{"\\\"E", "Ë"},
+		{"\\`I", "Ì"},
+		{"\\'I", "Í"},
+		{"\\^I", "Î"},
+		{"\\\"I", "Ï"},
+		{"\\~N", "Ñ"},
+		{"\\`O", "Ò"},
+		{"\\'O", "Ó"},
+		{"\\^O", "Ô"},
+		{"\\~O", "Õ"},
+		{"\\\"O", "Ö"},
+		{"\\`U", "Ù"},
+		{"\\'U", "Ú"},
+		{"\\^U", "Û"},
+		{"\\\"U", "Ü"},
+		{"\\'Y", "Ý"},
+		{"\\\"Y", "Ÿ"},
+		{"\\`a", "à"},
+		{"\\'a", "á"},
+		{"\\^a", "â"},
+		{"\\~a", "ã"},
+		{"\\\"a", "ä"},
+		{"\\`e", "è"},
+		{"\\'e", "é"},
+		{"\\^e", "ê"},
+		{"\\\"e", "ë"},
+		{"\\`\\i", "ì"},
+		{"\\'\\i", "í"},
+		{"\\^\\i", "î"},
+		{"\\\"\\i", "ï"},
+		{"\\~n", "ñ"},
+		{"\\`o", "ò"},
+		{"\\'o", "ó"},
+		{"\\^o", "ô"},
+		{"\\~o", "õ"},
+		{"\\\"o", "ö"},
+		{"\\`u", "ù"},
+		{"\\'u", "ú"},
+		{"\\^u", "û"},
+		{"\\\"u", "ü"},
+		{"\\'y", "ý"},
+		{"\\\"y", "ÿ"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		in := strings.NewReader(d.in)
+		if got := ToUnicode(&out, in); got != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.in, got)
+		}
+		if out.String() != d.out {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.in, out.String(), d.out)
+		}
+	}
+}
+
+func TestToLaTeX(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"ø", "{\\o}"},
+		{"Ø", "{\\O}"},
+		{"À", "\\`A"},
+		{"Á", "\\'A"},
+		{"Â", "\\^A"},
+		{"Ã", "\\~A"},
+		{"Ä", "\\\"A"},
+		{"Å", "{\\AA}"},
+		{"Æ", "{\\AE}"},
+		{"Ą", "\\k{A}"},
+		{"Ç", "\\c{C}"},
+		{"È", "\\`E"},
+		{"É", "\\'E"},
+		{"Ê", "\\^E"},
+		{"Ë", "\\\"E"},
+		{"Ę", "\\k{E}"},
+		{"Ì", "\\`I"},
+		{"Í", "\\'I"},
+		{"Î", "\\^I"},
+		{"Ï", "\\\"I"},
+		{"Į", "\\k{I}"},
+		{"Ñ", "\\~N"},
+		{"Ò", "\\`O"},
+		{"Ó", "\\'O"},
+		{"Ô", "\\^O"},
+		{"Õ", "\\~O"},
+		{"Ö", "\\\"O"},
+		{"Ő", "\\H{O}"},
+		{"Œ", "{\\OE}"},
+		{"Ù", "\\`U"},
+		{"Ú", "\\'U"},
+		{"Û", "\\^U"},
+		{"Ü", "\\\"U"},
+		{"Ű", "\\H{U}"},
+		{"Ų", "\\k{U}"},
+		{"Ý", "\\'Y"},
+		{"Ÿ", "\\\"Y"},
+		{"ß", "{\\ss}"},
+		{"à", "\\`a"},
+		{"á", "\\'a"},
+		{"â", "\\^a"},
+		{"ã", "\\~a"},
+		{"ä", "\\\"a"},
+		{"å", "{\\aa}"},
+		{"ą", "\\k{a}"},
+		{"æ", "{\\ae}"},
+		{"ç", "\\c{c}"},
+		{"è", "\\`e"},
+		{"é", "\\'e"},
+		{"ê", "\\^e"},
+		{"ë", "\\\"e"},
+		{"ę", "\\k{e}"},
+		{"ì", "\\`{\\i}"},
+		{"í", "\\'{\\i}"},
+		{"î", "\\^{\\i}"},
+		{"ï", "\\\"{\\i}"},
+		{"į", "\\k{i}"},
+		{"ñ", "\\~n"},
+		{"ò", "\\`o"},
+		{"ó", "\\'o"},
+		{"ô", "\\^o"},
+		{"õ", "\\~o"},
+		{"ö", "\\\"o"},
+		{"ő", "\\H{o}"},
+		{"œ", "{\\oe}"},
+		{"ù", "\\`u"},
+		{"ú", "\\'u"},
+		{"û", "\\^u"},
+		{"ü", "\\\"u"},
+		{"ű", "\\H{u}"},
+		{"ų", "\\k{u}"},
+		{"ý", "\\'y"},
+		{"ÿ", "\\\"y"},
+	}
+	context := []string{
+		"%s",
+		"before %s",
+		"%s after",
+		"before %s after",
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		for j, c := range context {
+			out.Reset()
+			inc := fmt.Sprintf(c, d.in)
+			in := strings.NewReader(inc)
+			if got := ToLaTeX(&out, in); got != nil {
+				t.Errorf("test %d.%d: ToLaTeX(%q) = %v, want nil", i, j, inc, got)
+			}
+			want := fmt.Sprintf(c, d.out)
+			if out.String() != want {
+				t.Errorf("test %d.%d: ToLaTeX(%q) = %q, want %q", i, j, inc, out.String(), want)
+			}
+		}
+	}
+}
+
+func TestVerbatim(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"é \\verb|é| é", "\\'e \\verb|é| \\'e"},
+		{"é\\begin{lstlisting}\nprint(\"é\")\n\\end{lstlisting}é", "\\'e\\begin{lstlisting}\nprint(\"é\")\n\\end{lstlisting}\\'e"},
+		{"\\begin{comment}ç\\end{comment}ç", "\\begin{comment}ç\\end{comment}\\c{c}"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		in := strings.NewReader(d.in)
+		if got := ToLaTeX(&out, in); got != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.in, got)
+		}
+		if out.String() != d.out {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.in, out.String(), d.out)
+		}
+	}
+}
+
+func TestMath(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"é $é$ é", "\\'e $é$ \\'e"},
+		{"é \\(é\\) \\[é\\] é", "\\'e \\(é\\) \\[é\\] \\'e"},
+		{"\\begin{equation}é\\end{equation}é", "\\begin{equation}é\\end{equation}\\'e"},
+		{"$\\text{é}$", "$\\text{\\'e}$"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		in := strings.NewReader(d.in)
+		if got := ToLaTeX(&out, in); got != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.in, got)
+		}
+		if out.String() != d.out {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.in, out.String(), d.out)
+		}
+	}
+}
+
+func TestMathAccents(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"$\\hat{x}$", "$x̂$"},
+		{"$\\bar{a} + \\vec{v}$", "$ā + v⃗$"},
+		{"\\(\\dot{x} = \\check{c}\\)", "\\(ẋ = č\\)"},
+		{"\\'e $\\acute{e}$", "é $é$"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), transformers.WithMath(true)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), transformers.WithMath(true)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	data := []struct {
+		latex, unicode, back string
+	}{
+		{"$\\alpha\\leq\\beta$", "$α≤β$", "$\\alpha\\leq\\beta$"},
+		{"$x\\neq y$ and $n\\to\\infty$", "$x≠y$ and $n→∞$", "$x\\neq y$ and $n\\to\\infty$"},
+		{"$\\sum_{i\\in I} x_i$", "$∑_{i∈I} x_i$", "$\\sum_{i\\in I} x_i$"},
+		{"$\\hat\\theta$", "$θ̂$", "$\\hat{\\theta}$"},
+		{"l'angle $\\alpha$", "l'angle $α$", "l'angle $\\alpha$"},
+		{"l'angle α", "l'angle α", "l'angle $\\alpha$"},
+	}
+
+	opts := []transformers.Option{transformers.WithMath(true), transformers.WithSymbols(true)}
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), opts...); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), opts...); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.back {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.back)
+		}
+	}
+}
+
+func TestMathAlphabets(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"$f\\colon \\mathbb{R}^n\\to \\mathbb{R}$", "$f\\colon ℝ^n\\to ℝ$"},
+		{"$\\mathcal{O}(n)$ and $\\mathfrak{g}$", "$𝒪(n)$ and $𝔤$"},
+		{"$\\mathbb{NZ}\\mathbf{x}$", "$ℕℤ𝐱$"},
+	}
+
+	opts := []transformers.Option{transformers.WithMath(true), transformers.WithMathAlphabets(true)}
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), opts...); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), opts...); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestScripts(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"$x^{2}+y^{2}=z^{2}$", "$x²+y²=z²$"},
+		{"$a_{n+1}=a_{n}+a_{n-1}$", "$aₙ₊₁=aₙ+aₙ₋₁$"},
+		{"$x^{q}$ and H\\textsubscript{2}O", "$x^{q}$ and H₂O"},
+	}
+
+	opts := []transformers.Option{transformers.WithMath(true), transformers.WithScripts(true)}
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), opts...); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), opts...); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestTextSymbols(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"{\\texteuro}5", "€5"},
+		{"{\\copyright} 2024", "© 2024"},
+		{"{\\S}3", "§3"},
+		{"{\\textdegree}C", "°C"},
+		{"{\\textpm}1", "±1"},
+		{"{\\textquestiondown}Qu\\'e?", "¿Qué?"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	data := []struct {
+		quotes         transformers.Quotes
+		latex, unicode string
+	}{
+		{transformers.EnglishQuotes, "``Hello'', `x' and l'angle", "“Hello”, ‘x’ and l’angle"},
+		{transformers.GermanQuotes, "\\glqq Text\\grqq{} and \\glq x\\grq", "„Text“ and ‚x‘"},
+		{transformers.FrenchQuotes, "\\guillemotleft\\,Salut\\,\\guillemotright", "« Salut »"},
+		{transformers.PolishQuotes, ",,Tak'' $a<<b$", "„Tak” $a<<b$"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), transformers.WithQuotes(d.quotes)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), transformers.WithQuotes(d.quotes)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestDashes(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"pages 1--2 --- or not\\ldots{} so~far", "pages 1–2 — or not… so\u00a0far"},
+		{"a\\,b hy\\-phen $x\\ldots y$", "a\u202fb hy\u00adphen $x\\ldots y$"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), transformers.WithDashes(true)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), transformers.WithDashes(true)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestEscapeSpecials(t *testing.T) {
+	data := []struct {
+		unicode, latex string
+	}{
+		{"R&D 50% _draft_ #1", "R\\&D 50\\% \\_draft\\_ \\#1"},
+		{"% déjà $x$ \\verb|é|", "\\% d\\'ej\\`a \\$x\\$ \\textbackslash{}verb|\\'e|"},
+		{"{~^}", "\\{\\textasciitilde{}\\textasciicircum{}\\}"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), transformers.WithEscapeSpecials(true)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestPlain(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"R\\&D 50\\% \\_draft\\_ \\#1", "R&D 50% _draft_ #1"},
+		{"{The} {\\'E}cole \\emph{d'\\'et\\'e}", "The École \\emph{d'été}"},
+		{"a\\textbackslash{}b \\{\\textasciitilde{}\\}", "a\\b {~}"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), transformers.WithPlain(true)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+	}
+	// the plain text is escaped back
+	out.Reset()
+	if err := ToLaTeX(&out, strings.NewReader("R&D {~} a\\b"), transformers.WithEscapeSpecials(true)); err != nil {
+		t.Errorf("ToLaTeX = %v, want nil", err)
+	}
+	var back bytes.Buffer
+	if err := ToUnicode(&back, &out, transformers.WithPlain(true)); err != nil {
+		t.Errorf("ToUnicode = %v, want nil", err)
+	}
+	if back.String() != "R&D {~} a\\b" {
+		t.Errorf("round trip = %q, want %q", back.String(), "R&D {~} a\\b")
+	}
+}
+
+func TestBraces(t *testing.T) {
+	data := []struct {
+		opts           []transformers.Option
+		latex, unicode string
+	}{
+		{nil, "{\\'e} x {\\'E}cole Fran{\\c{c}}ois \\emph{\\'e} {Foo}", "é x École François \\emph{é} {Foo}"},
+		{[]transformers.Option{transformers.WithBraces(transformers.KeepBraces)}, "{\\'e} x {\\ss}", "{é} x {ß}"},
+		{[]transformers.Option{transformers.WithBraces(transformers.StripRedundantBraces)}, "{The} {\\'e}t\\'e {\\em x} $x^{2}$", "The été {\\em x} $x^{2}$"},
+		{[]transformers.Option{transformers.WithBraces(transformers.StripRedundantBraces), transformers.WithProtectCase(true)}, "{The} {NASA} {\\'E}cole {\\'e}t\\'e", "{The} {NASA} {É}cole été"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), d.opts...); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+	}
+}
+
+func TestStyles(t *testing.T) {
+	data := []struct {
+		opts           []transformers.Option
+		unicode, latex string
+	}{
+		{[]transformers.Option{transformers.WithAccentArgs(transformers.BracedAccentArgs)}, "déçu ß", "d\\'{e}\\c{c}u {\\ss}"},
+		{[]transformers.Option{transformers.WithAccentArgs(transformers.UnbracedAccentArgs)}, "déçu", "d\\'e\\c cu"},
+		{[]transformers.Option{transformers.WithCharGroups(true)}, "déçu ß", "d{\\'e}{\\c{c}}u {\\ss}"},
+		{[]transformers.Option{transformers.WithTerminator(transformers.EmptyTerminator)}, "ß œuvre", "\\ss{} \\oe{}uvre"},
+		{[]transformers.Option{transformers.WithTerminator(transformers.SpaceTerminator)}, "ß œuvre", "\\ss{} \\oe uvre"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), d.opts...); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+		// all the styles are converted back
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+	}
+}
+
+func TestBibTeX(t *testing.T) {
+	data := []struct {
+		unicode, latex string
+	}{
+		{"Kurt Gödel", "Kurt G{\\\"{o}}del"},
+		{"Straße, Ørsted, Ångström", "Stra{\\ss}e, {\\O}rsted, {\\AA}ngstr{\\\"{o}}m"},
+		{"Çelik Erdoğan", "{\\c{C}}elik Erdo{\\u{g}}an"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), transformers.WithBibTeX(true)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+	}
+}
+
+func TestBibFields(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"@article{k, author = {Kurt G{\\\"o}del}, url = {http://x.org/G\\\"odel}}", "@article{k, author = {Kurt Gödel}, url = {http://x.org/G\\\"odel}}"},
+		{"@book{k,\n  title = \"Fran{\\c{c}}ais\",\n  file = {\\'e.pdf},\n}", "@book{k,\n  title = \"Français\",\n  file = {\\'e.pdf},\n}"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex), transformers.WithBibFields()); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode), transformers.WithBibFields()); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestMoreAccents(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"Bucure\\textcommabelow{s}ti, \\textcommabelow{T}ara", "București, Țara"},
+		{"Vi\\^\\d{e}t \\h{a} \\G{a}\\f{a}", "Việt ả ȁȃ"},
+		{"\\textsubring{a} \\U{a} \\k{o}", "ḁ a̎ ǫ"},
+		{"\\t{ts}ar, \\t{dz}", "t͡sar, d͡z"},
+		{"\\t{dʒ}a \\t{tʃ}a", "d͡ʒa t͡ʃa"},
+		{"\\t{\\i u} \\t{\\o o}", "ı͡u ø͡o"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestSpecialLetters(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"{\\TH}\\'orr and {\\th}at, {\\DH}{\\dh}", "Þórr and þat, Ðð"},
+		{"{\\DJ}ur{\\dj}a, {\\NG}{\\ng}, {\\IJ}ssel {\\ij}s", "Đurđa, Ŋŋ, Ĳssel ĳs"},
+		{"{\\SS} {\\textHbar}{\\texthbar} {\\Ldot}{\\ldot} {\\textkra}", "ẞ Ħħ Ŀŀ ĸ"},
+		{"\\'{\\dh} \\v{\\dj}", "ð́ đ̌"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+}
+
+func TestAccentBases(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"\\'{\\ae} \\'{\\o} \\\"{\\oe} \\~{\\ng}", "ǽ ǿ œ̈ ŋ̃"},
+		{"\\'1 \\'\\\"u \\'\\^e \\=\\k{o}", "1́ ǘ ế ǭ"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+	// the braced and nested forms give the same characters
+	out.Reset()
+	if err := ToUnicode(&out, strings.NewReader("\\'{ø} \\'{\\\"{u}} \\'{\\^{e}}")); err != nil {
+		t.Errorf("ToUnicode = %v, want nil", err)
+	}
+	if out.String() != "ǿ ǘ ế" {
+		t.Errorf("ToUnicode = %q, want %q", out.String(), "ǿ ǘ ế")
+	}
+}
+
+func TestDotless(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"\\={\\i} \\u{\\i} \\v{\\i} \\~{\\i} \\v{\\j}", "ī ĭ ǐ ĩ ǰ"},
+		{"\\'{\\i}\\`{\\i}\\^{\\i}\\\"{\\i} \\d{i}", "íìîï ị"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+	// the literal dotless letters can be kept
+	out.Reset()
+	if err := ToUnicode(&out, strings.NewReader("\\'{\\i} \\v\\j"), transformers.WithKeepDotless(true)); err != nil {
+		t.Errorf("ToUnicode = %v, want nil", err)
+	}
+	if want := "ı́ ȷ̌"; out.String() != want {
+		t.Errorf("ToUnicode = %q, want %q", out.String(), want)
+	}
+}
+
+func TestSpacingAccents(t *testing.T) {
+	data := []struct {
+		latex, unicode string
+	}{
+		{"\\'{} \\`{} \\^{} \\~{} \\\"{}", "´ ˋ ˆ ˜ ¨"},
+		{"\\={}\\u{}\\.{}\\r{}\\H{}\\v{}", "¯˘˙˚˝ˇ"},
+		{"\\c{}, \\k{} and \\b{}", "¸, ˛ and ˍ"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.latex)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.latex, err)
+		}
+		if out.String() != d.unicode {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.latex, out.String(), d.unicode)
+		}
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.unicode)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.unicode, err)
+		}
+		if out.String() != d.latex {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.unicode, out.String(), d.latex)
+		}
+	}
+	// the accents without spacing form keep their empty argument, and do not give a lone combining mark
+	out.Reset()
+	if err := ToUnicode(&out, strings.NewReader("\\d{} \\t{}, \\'\\d{}")); err != nil {
+		t.Errorf("ToUnicode = %v, want nil", err)
+	}
+	if out.String() != "\\d{} \\t{}, \u00A0\u0323\u0301" {
+		t.Errorf("ToUnicode = %q, want %q", out.String(), "\\d{} \\t{}, \u00A0\u0323\u0301")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	data := []struct {
+		fallback transformers.Fallback
+		in, out  string
+	}{
+		{transformers.KeepFallback, "Ŋ ж ờ", "{\\NG} ж \\`ơ"},
+		{transformers.SymbolFallback, "Ŋ ж ờ", "{\\NG} \\symbol{\"0436} \\`o\\symbol{\"031B}"},
+		{transformers.CharFallback, "жé", "{\\char\"0436}\\'e"},
+		{transformers.HatFallback, "жé", "^^^^0436\\'e"},
+		{transformers.UnicharFallback, "жé", "\\unichar{1078}\\'e"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToLaTeX(&out, strings.NewReader(d.in), transformers.WithFallback(d.fallback)); err != nil {
+			t.Errorf("test %d: ToLaTeX(%q) = %v, want nil", i, d.in, err)
+		}
+		if out.String() != d.out {
+			t.Errorf("test %d: ToLaTeX(%q) = %q, want %q", i, d.in, out.String(), d.out)
+		}
+	}
+	// the error lists the characters without LaTeX form with their lines and columns
+	out.Reset()
+	err := ToLaTeX(&out, strings.NewReader("é\nж ŋ\n$ж$ ơ"), transformers.WithFallback(transformers.ErrorFallback))
+	want := "no LaTeX form for the characters 'ж' (U+0436) on line 2, column 1, '̛' (U+031B) on line 3, column 5"
+	if err == nil || err.Error() != want {
+		t.Errorf("ToLaTeX = %v, want %q", err, want)
+	}
+	if out.Len() != 0 {
+		t.Errorf("ToLaTeX wrote %q, want nothing", out.String())
+	}
+}
+
+func TestCharCodes(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"\\char\"E9t\\'e \\char233 x", "été éx"},
+		{"\\symbol{\"0436}{\\char\"0436}\\unichar{1078}^^^^0436", "жжжж"},
+		{"^^e9 \\accent\"7F e \\accent\"04{\\i}", "é ë ï"},
+		{"\\char\"5C \\char\"7B x^^M", "\\char\"5C \\char\"7B x^^M"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.in)); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.in, err)
+		}
+		if out.String() != d.out {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.in, out.String(), d.out)
+		}
+	}
+	// the fallback forms are decoded back
+	for _, f := range []transformers.Fallback{transformers.SymbolFallback, transformers.CharFallback, transformers.HatFallback, transformers.UnicharFallback} {
+		var latex, unicode bytes.Buffer
+		if err := ToLaTeX(&latex, strings.NewReader("Ŋ жй ờ"), transformers.WithFallback(f)); err != nil {
+			t.Errorf("%s: ToLaTeX = %v, want nil", f, err)
+		}
+		if err := ToUnicode(&unicode, &latex); err != nil {
+			t.Errorf("%s: ToUnicode = %v, want nil", f, err)
+		}
+		if unicode.String() != "Ŋ жй ờ" {
+			t.Errorf("%s: round trip = %q, want %q", f, unicode.String(), "Ŋ жй ờ")
+		}
+	}
+	// the codes in math are kept with TextCharCodes
+	out.Reset()
+	in := "^^e9 $^^e9$"
+	if err := ToUnicode(&out, strings.NewReader(in), transformers.WithMath(true), transformers.WithCharCodes(transformers.TextCharCodes)); err != nil {
+		t.Errorf("ToUnicode = %v, want nil", err)
+	}
+	if want := "é $^^e9$"; out.String() != want {
+		t.Errorf("ToUnicode(%q) = %q, want %q", in, out.String(), want)
+	}
+}
+
+func TestShorthands(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"\\usepackage[ngerman]{babel}\n\"`Gr\"u\"se\"' % \"a\n$\"a$ \\verb|\"a| \"O", "\\usepackage[ngerman]{babel}\n„Grüße“ % \"a\n$\"a$ \\verb|\"a| Ö"},
+		{"\"a \\begin{otherlanguage}{ngerman}\"a \\'e\\end{otherlanguage} \"a", "\"a \\begin{otherlanguage}{ngerman}ä é\\end{otherlanguage} \"a"},
+		{"\\selectlanguage{dutch}\"`Co\"ordinatie\"'", "\\selectlanguage{dutch}„Coördinatie”"},
+	}
+
+	var out bytes.Buffer // the output writer
+	for i, d := range data {
+		out.Reset()
+		if err := ToUnicode(&out, strings.NewReader(d.in), transformers.WithShorthands()); err != nil {
+			t.Errorf("test %d: ToUnicode(%q) = %v, want nil", i, d.in, err)
+		}
+		if out.String() != d.out {
+			t.Errorf("test %d: ToUnicode(%q) = %q, want %q", i, d.in, out.String(), d.out)
+		}
+	}
+	// the shorthands are not converted by default
+	out.Reset()
+	if err := ToUnicode(&out, strings.NewReader("\\selectlanguage{ngerman}\"a")); err != nil {
+		t.Errorf("ToUnicode = %v, want nil", err)
+	}
+	if out.String() != "\\selectlanguage{ngerman}\"a" {
+		t.Errorf("ToUnicode = %q, want %q", out.String(), "\\selectlanguage{ngerman}\"a")
+	}
+}
@@ -0,0 +1,162 @@
+// bib package is a package that reads the entries of .bib files
+// and finds the entries that differ only in their accents spelling.
+package bib
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/kpym/laxents/internal/bibtex"
+)
+
+// Entry is an entry of a .bib file
+type Entry struct {
+	Type   string            // the entry type in lowercase, like article
+	Key    string            // the citation key
+	Line   int               // the line of the `@` that starts the entry (from 1)
+	Fields map[string]string // the LaTeX values of the fields by lowercase name, without delimiters
+}
+
+// parser reads the entries of a .bib source
+type parser struct {
+	src     []byte
+	pos     int
+	strings map[string]string // the @string abbreviations by lowercase name
+}
+
+// skipSpaces moves the position after the white spaces
+func (p *parser) skipSpaces() {
+	p.pos += bibtex.SkipSpaces(p.src[p.pos:])
+}
+
+// name reads a field name, an entry type or an abbreviation
+func (p *parser) name() string {
+	name, _ := bibtex.Name(p.src[p.pos:])
+	p.pos += len(name)
+	return name
+}
+
+// delimited reads a {...} or "..." part of a value (starting after its opening delimiter)
+// and returns its content. Like BibTeX, it counts all the braces, even escaped ones.
+func (p *parser) delimited(quoted bool) string {
+	start := p.pos
+	end, _ := bibtex.ValueEnd(p.src[start:], 0, quoted)
+	if end < 0 {
+		p.pos = len(p.src)
+		return string(p.src[start:])
+	}
+	p.pos += end + 1
+	return string(p.src[start : start+end])
+}
+
+// value reads the parts of a value joined by `#` and returns their concatenation.
+// The abbreviations are replaced by their @string definition, if any.
+func (p *parser) value() string {
+	var b strings.Builder
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.src) {
+			return b.String()
+		}
+		switch p.src[p.pos] {
+		case '{':
+			p.pos++
+			b.WriteString(p.delimited(false))
+		case '"':
+			p.pos++
+			b.WriteString(p.delimited(true))
+		default:
+			name := p.name()
+			if s, ok := p.strings[strings.ToLower(name)]; ok {
+				name = s
+			}
+			b.WriteString(name)
+		}
+		p.skipSpaces()
+		if p.pos == len(p.src) || p.src[p.pos] != '#' {
+			return b.String()
+		}
+		p.pos++
+	}
+}
+
+// fields reads the fields of an entry up to its closing delimiter
+func (p *parser) fields(closer byte) map[string]string {
+	fields := make(map[string]string)
+	for p.pos < len(p.src) {
+		p.skipSpaces()
+		if p.pos == len(p.src) {
+			break
+		}
+		switch p.src[p.pos] {
+		case ',':
+			p.pos++
+			continue
+		case closer:
+			p.pos++
+			return fields
+		}
+		name := p.name()
+		p.skipSpaces()
+		if p.pos == len(p.src) || p.src[p.pos] != '=' {
+			// a malformed field, skip a byte to go on
+			p.pos = min(p.pos+1, len(p.src))
+			continue
+		}
+		p.pos++
+		fields[strings.ToLower(name)] = p.value()
+	}
+	return fields
+}
+
+// Parse returns the entries of the .bib source src.
+// The @string abbreviations are expanded in the values of the fields,
+// the @comment and @preamble entries and the text between the entries are ignored.
+func Parse(src []byte) []Entry {
+	p := &parser{src: src, strings: make(map[string]string)}
+	var entries []Entry
+	line, counted := 1, 0 // the line number at the position counted
+	for {
+		i := bytes.IndexByte(p.src[p.pos:], '@')
+		if i < 0 {
+			return entries
+		}
+		p.pos += i
+		line += bytes.Count(p.src[counted:p.pos], []byte("\n"))
+		counted = p.pos
+		p.pos++
+		typ := strings.ToLower(p.name())
+		p.skipSpaces()
+		if p.pos == len(p.src) || (p.src[p.pos] != '{' && p.src[p.pos] != '(') {
+			// a `@` outside the entries
+			continue
+		}
+		closer := byte('}')
+		if p.src[p.pos] == '(' {
+			closer = ')'
+		}
+		p.pos++
+		switch typ {
+		case "comment", "preamble":
+			if closer == '}' {
+				p.delimited(false)
+			} else if i := bytes.IndexByte(p.src[p.pos:], ')'); i >= 0 {
+				p.pos += i + 1
+			}
+		case "string":
+			for name, s := range p.fields(closer) {
+				p.strings[name] = s
+			}
+		default:
+			p.skipSpaces()
+			e := Entry{Type: typ, Line: line}
+			start := p.pos
+			for p.pos < len(p.src) && p.src[p.pos] != ',' && p.src[p.pos] != closer {
+				p.pos++
+			}
+			e.Key = strings.TrimSpace(string(p.src[start:p.pos]))
+			e.Fields = p.fields(closer)
+			entries = append(entries, e)
+		}
+	}
+}
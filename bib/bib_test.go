@@ -0,0 +1,34 @@
+package bib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `% comment @ here
+@string{pe = "Erd{\H o}s"}
+@Article{e1,
+  author = pe # ", Paul",
+  title  = {On {Some} "Problems"},
+  year   = 1947,
+}
+@comment{@book{x, title = {Ignored}}}
+@book(e2, Title = "A {\"U}ber")
+`
+	exp := []Entry{
+		{Type: "article", Key: "e1", Line: 3, Fields: map[string]string{
+			"author": "Erd{\\H o}s, Paul",
+			"title":  "On {Some} \"Problems\"",
+			"year":   "1947",
+		}},
+		{Type: "book", Key: "e2", Line: 9, Fields: map[string]string{
+			"title": "A {\\\"U}ber",
+		}},
+	}
+
+	got := Parse([]byte(src))
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %#v, got %#v", exp, got)
+	}
+}
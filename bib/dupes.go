@@ -0,0 +1,131 @@
+package bib
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kpym/laxents/api"
+	"github.com/kpym/laxents/transformers"
+)
+
+// Canonical returns the canonical form of the LaTeX value s:
+// the accents and the special characters are converted to Unicode (NFC),
+// the remaining macros, like \emph, and the braces are removed,
+// the letters are in lowercase and the spaces are collapsed.
+// So Erd{\H o}s, Erd\H{o}s and Erdős have the same canonical form.
+// It returns an error if the conversion to Unicode fails.
+func Canonical(s string) (string, error) {
+	var out bytes.Buffer
+	err := api.ToUnicode(&out, strings.NewReader(s),
+		transformers.WithPlain(true),
+		transformers.WithDashes(true),
+		transformers.WithQuotes(transformers.EnglishQuotes))
+	if err != nil {
+		return "", err
+	}
+	// the names of the macros are separators, the braces are removed
+	s = macrosAndBraces.ReplaceAllStringFunc(out.String(), func(m string) string {
+		if m[0] == '\\' {
+			return " "
+		}
+		return ""
+	})
+	return strings.Join(strings.Fields(strings.ToLower(s)), " "), nil
+}
+
+// macrosAndBraces matches the control words, like \emph, and the braces
+var macrosAndBraces = regexp.MustCompile(`\\[A-Za-z@]+|[{}]`)
+
+// dupesFields are the fields compared to find the duplicated entries
+var dupesFields = []string{"author", "title"}
+
+// canonicalEntry returns the canonical form of the compared fields of e,
+// or an empty string if e has none of them.
+func canonicalEntry(e Entry) (string, error) {
+	var parts []string
+	empty := true
+	for _, f := range dupesFields {
+		c, err := Canonical(e.Fields[f])
+		if err != nil {
+			return "", fmt.Errorf("entry %s (line %d), field %s: %w", e.Key, e.Line, f, err)
+		}
+		empty = empty && c == ""
+		parts = append(parts, c)
+	}
+	if empty {
+		return "", nil
+	}
+	return strings.Join(parts, " / "), nil
+}
+
+// Dupes is a group of entries whose compared fields have the same canonical form (or similar ones)
+type Dupes struct {
+	Canonical string  // the canonical form of the first entry
+	Entries   []Entry // the entries in the order of the source
+}
+
+// FindDupes returns the groups of entries whose author and title fields
+// have similar canonical forms, in the order of their first entry.
+// The similarity is between 0 and 1 and is based on the edit distance,
+// with threshold 1 only the identical canonical forms are grouped.
+// The entries without author and title are ignored.
+// It returns an error if the canonical form of an entry cannot be computed.
+func FindDupes(entries []Entry, threshold float64) ([]Dupes, error) {
+	var groups []Dupes
+	for _, e := range entries {
+		c, err := canonicalEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		if c == "" {
+			continue
+		}
+		found := false
+		for i := range groups {
+			if c == groups[i].Canonical || (threshold < 1 && similarity(c, groups[i].Canonical) >= threshold) {
+				groups[i].Entries = append(groups[i].Entries, e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, Dupes{Canonical: c, Entries: []Entry{e}})
+		}
+	}
+	// keep only the groups with duplicates
+	dupes := groups[:0]
+	for _, g := range groups {
+		if len(g.Entries) > 1 {
+			dupes = append(dupes, g)
+		}
+	}
+	return dupes, nil
+}
+
+// similarity returns 1 minus the edit distance between the runes of a and b
+// divided by the length of the longest one
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	// the Levenshtein distance with a single row
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			diag, row[j] = row[j], min(row[j]+1, row[j-1]+1, diag+cost)
+		}
+	}
+	return 1 - float64(row[len(rb)])/float64(max(len(ra), len(rb)))
+}
@@ -0,0 +1,96 @@
+package bib
+
+import (
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	data := []struct {
+		src string
+		exp string
+	}{
+		{"Erd{\\H o}s", "erdős"},
+		{"Erd\\H{o}s", "erdős"},
+		{"Erdős", "erdős"},
+		{"{On}  Some\n{\\'E}tudes", "on some études"},
+		{"Fran\\c{c}ois -- R\\&D", "françois – r&d"},
+		{"\\emph{Gauss} \\textsc{C.F.}", "gauss c.f."},
+	}
+
+	for i, d := range data {
+		got, err := Canonical(d.src)
+		if err != nil {
+			t.Errorf("test %d: Canonical(%q) = %v, want nil", i, d.src, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: Canonical(%q) = %q, want %q", i, d.src, got, d.exp)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	data := []struct {
+		a, b string
+		exp  float64
+	}{
+		{"", "", 1},
+		{"abc", "abc", 1},
+		{"abc", "", 0},
+		{"abcd", "abed", 0.75},
+		{"erdős", "erdos", 0.8},
+		{"kitten", "sitting", 1 - 3.0/7},
+	}
+
+	for i, d := range data {
+		if got := similarity(d.a, d.b); got != d.exp {
+			t.Errorf("test %d: similarity(%q, %q) = %v, want %v", i, d.a, d.b, got, d.exp)
+		}
+	}
+}
+
+func TestFindDupes(t *testing.T) {
+	src := `@article{e1, author = {Erd{\H o}s, Paul}, title = {On some problems}}
+@article{e2, author = {Erd\H{o}s, Paul}, title = "On {S}ome problems"}
+@article{g1, author = {G{\"o}del, Kurt}, title = {{\"U}ber}}
+@book{e3, author = {Erdős, Paul}, title = {On some problems}}
+@book{e4, author = {Erdős, P.}, title = {On some problems}}
+@misc{n1, year = 2000}
+@misc{n2, year = 2000}
+`
+	entries := Parse([]byte(src))
+	data := []struct {
+		threshold float64
+		exp       [][]string // the keys of the groups
+	}{
+		{1, [][]string{{"e1", "e2", "e3"}}},
+		{0.9, [][]string{{"e1", "e2", "e3", "e4"}}},
+		{0, [][]string{{"e1", "e2", "g1", "e3", "e4"}}},
+	}
+
+	for i, d := range data {
+		got, err := FindDupes(entries, d.threshold)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if len(got) != len(d.exp) {
+			t.Errorf("test %d: expected %d groups, got %d", i, len(d.exp), len(got))
+			continue
+		}
+		for j, g := range got {
+			var keys []string
+			for _, e := range g.Entries {
+				keys = append(keys, e.Key)
+			}
+			if len(keys) != len(d.exp[j]) {
+				t.Errorf("test %d: expected group %v, got %v", i, d.exp[j], keys)
+				continue
+			}
+			for k := range keys {
+				if keys[k] != d.exp[j][k] {
+					t.Errorf("test %d: expected group %v, got %v", i, d.exp[j], keys)
+					break
+				}
+			}
+		}
+	}
+}
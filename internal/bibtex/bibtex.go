@@ -0,0 +1,49 @@
+// bibtex package contains the helpers that read the tokens of the .bib files,
+// shared by the transformers and the bib packages.
+package bibtex
+
+import "strings"
+
+// IsSpace returns true if c is a white space between the .bib tokens
+func IsSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// SkipSpaces returns the number of white spaces at the beginning of src
+func SkipSpaces(src []byte) int {
+	n := 0
+	for n < len(src) && IsSpace(src[n]) {
+		n++
+	}
+	return n
+}
+
+// Name returns the field name, the entry type or the abbreviation at the beginning of src.
+// It returns needMore if src is too short to know the whole name.
+func Name(src []byte) (name string, needMore bool) {
+	n := 0
+	for n < len(src) && !IsSpace(src[n]) && !strings.ContainsRune("=,{}()\"#@", rune(src[n])) {
+		n++
+	}
+	return string(src[:n]), n == len(src)
+}
+
+// ValueEnd returns the position of the byte that closes the value part in src, or -1 if it is not found,
+// and the brace depth after src[:end] (or src), starting at depth.
+// The part is a {...} one, or a "..." one if quoted is true, and src starts after its opening delimiter.
+// Like BibTeX, it counts all the braces, even escaped ones.
+func ValueEnd(src []byte, depth int, quoted bool) (end, d int) {
+	for i, c := range src {
+		switch {
+		case c == '{':
+			depth++
+		case c == '}' && depth == 0 && !quoted:
+			return i, depth
+		case c == '}':
+			depth--
+		case c == '"' && depth == 0 && quoted:
+			return i, depth
+		}
+	}
+	return -1, depth
+}
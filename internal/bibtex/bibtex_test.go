@@ -0,0 +1,72 @@
+package bibtex
+
+import "testing"
+
+func TestSkipSpaces(t *testing.T) {
+	data := []struct {
+		src string
+		exp int
+	}{
+		{"", 0},
+		{" \t\r\nx ", 4},
+		{"x ", 0},
+	}
+
+	for i, d := range data {
+		if n := SkipSpaces([]byte(d.src)); n != d.exp {
+			t.Errorf("test %d: expected %d, got %d", i, d.exp, n)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	data := []struct {
+		src     string
+		exp     string
+		expMore bool
+	}{
+		{"", "", true},
+		{"tit", "tit", true},
+		{"title = {x}", "title", false},
+		{"title={x}", "title", false},
+		{"article{key,", "article", false},
+		{"book(key,", "book", false},
+		{"{x}", "", false},
+	}
+
+	for i, d := range data {
+		name, more := Name([]byte(d.src))
+		if name != d.exp {
+			t.Errorf("test %d: expected name=%q, got name=%q", i, d.exp, name)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestValueEnd(t *testing.T) {
+	data := []struct {
+		src    string
+		depth  int
+		quoted bool
+		expEnd int
+		expD   int
+	}{
+		{"abc}", 0, false, 3, 0},
+		{"a{b}c}", 0, false, 5, 0},
+		{"a{b", 0, false, -1, 1},
+		{"b}c}", 1, false, 3, 0},
+		{"a\\}b}", 0, false, 2, 0},
+		{"a\"b", 0, false, -1, 0},
+		{"a{\\\"o}\"", 0, true, 6, 0},
+		{"a}\"", 1, true, 2, 0},
+	}
+
+	for i, d := range data {
+		end, depth := ValueEnd([]byte(d.src), d.depth, d.quoted)
+		if end != d.expEnd || depth != d.expD {
+			t.Errorf("test %d: expected (%d, %d), got (%d, %d)", i, d.expEnd, d.expD, end, depth)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package parameters
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alexflint/go-arg"
+)
+
+// the parameters for the bib-dupes command
+type DupesArgs struct {
+	Input     string  `arg:"positional" help:".bib file (stdin if missing)"`
+	Threshold float64 `arg:"-t,--threshold" default:"1" help:"the minimal similarity (between 0 and 1) of the duplicated entries, 1 for identical canonical forms"`
+}
+
+func (DupesArgs) Description() string {
+	return "report the .bib entries whose author and title differ only in their accents spelling"
+}
+
+// DupesParameters for the bib-dupes command
+// returned by the GetDupes function
+type DupesParameters struct {
+	Threshold float64
+	In        io.ReadCloser
+}
+
+// GetDupes parses the command line arguments of the bib-dupes command and returns its parameters
+func GetDupes(osArgs []string) (params *DupesParameters, err error) {
+	// capture the panic and return it as an error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	var args DupesArgs
+	params = &DupesParameters{}
+
+	p, err := arg.NewParser(arg.Config{Program: "laxents bib-dupes"}, &args)
+	check(err, "cannot create parser")
+	p.MustParse(osArgs)
+
+	if args.Threshold < 0 || args.Threshold > 1 {
+		return nil, fmt.Errorf("the threshold %v is not between 0 and 1", args.Threshold)
+	}
+	params.Threshold = args.Threshold
+
+	// get the input
+	if args.Input != "" {
+		f, err := os.Open(args.Input)
+		check(err, "cannot open input file")
+		params.In = f
+	} else {
+		// check if there is data on stdin
+		stat, _ := os.Stdin.Stat()
+		if (stat.Mode() & os.ModeCharDevice) == 0 {
+			params.In = io.NopCloser(os.Stdin)
+		}
+	}
+	if params.In == nil {
+		return nil, errors.New("no input data provided")
+	}
+
+	return params, nil
+}
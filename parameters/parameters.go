@@ -1,138 +1,232 @@
-// parameters is a package that handles the command line parameters
-package parameters
-
-import (
-	"errors"
-	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"strings"
-
-	"github.com/alexflint/go-arg"
-)
-
-// check is a helper function to check for errors
-func check(e error, msg string) {
-	if e != nil {
-		panic(fmt.Errorf("%s: %v", msg, e))
-	}
-}
-
-// nopWriteCloser is a WriteCloser that does nothing on Close
-type nopWriteCloser struct {
-	io.Writer
-}
-
-func (nopWriteCloser) Close() error { return nil }
-
-// the parameters for the program
-type Args struct {
-	ToUnicode bool   `arg:"-u,--to-unicode" help:"convert from LaTeX to Unicode"`
-	ToLatex   bool   `arg:"-l,--to-latex" help:"convert from Unicode to LaTeX"`
-	Input     string `arg:"-i,--input" help:"input file"`
-	Output    string `arg:"-o,--output" help:"output file"`
-	Text      string `arg:"positional" help:"string to convert"`
-}
-
-func (Args) Description() string {
-	return "convert between LaTeX accents and Unicode characters"
-}
-
-func (Args) Epilogue() string {
-	// get the executable name without the path
-	exe := filepath.Base(os.Args[0])
-	// remove the .exe extension on Windows
-	if filepath.Ext(exe) == ".exe" {
-		exe = exe[:len(exe)-4]
-	}
-	return fmt.Sprintf(`Examples:
-	%s -to-latex "déçû"
-	%s -to-unicode "d\\'e\\c{c}\\^{u}"
-	%s -to-unicode -i input.tex -o output.tex
-	%s -to-latex -i input.tex -o output.tex
-	cat input.tex | %s -to-unicode
-	`, exe, exe, exe, exe, exe)
-}
-
-// PrintHelp prints the help message
-func PrintHelp() {
-	p := arg.MustParse(&Args{})
-	p.WriteHelp(os.Stdout)
-}
-
-// Parameters for the program
-// returned by the Get function
-type Parameters struct {
-	ToUnicode bool
-	In        io.ReadCloser
-	Out       io.WriteCloser
-}
-
-var (
-	// the missing input error
-	ErrMissingParameters = errors.New("missing parameters")
-)
-
-// Get parses the command line arguments and returns the parameters
-func Get(osArgs []string) (params *Parameters, err error) {
-	// check if there are arguments
-	if len(osArgs) == 0 {
-		return nil, ErrMissingParameters
-	}
-
-	// capture the panic and return it as an error
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("%v", r)
-		}
-	}()
-
-	var args Args
-	params = &Parameters{}
-
-	p, err := arg.NewParser(arg.Config{}, &args)
-	check(err, "cannot create parser")
-	p.MustParse(osArgs)
-
-	// get the replacer
-	if args.ToUnicode && args.ToLatex {
-		return nil, errors.New("cannot specify both -to-unicode and -to-latex")
-	}
-	if !args.ToUnicode && !args.ToLatex {
-		return nil, errors.New("must specify either -to-unicode or -to-latex")
-	}
-	params.ToUnicode = args.ToUnicode
-
-	// get the input
-	if args.Input != "" && args.Text != "" {
-		return nil, errors.New("cannot specify both a file and a string")
-	}
-	if args.Input != "" {
-		f, err := os.Open(args.Input)
-		check(err, "cannot open input file")
-		params.In = f
-	} else if args.Text != "" {
-		params.In = io.NopCloser(strings.NewReader(args.Text))
-	} else {
-		// check if there is data on stdin
-		stat, _ := os.Stdin.Stat()
-		if (stat.Mode() & os.ModeCharDevice) == 0 {
-			params.In = io.NopCloser(os.Stdin)
-		}
-	}
-	if params.In == nil {
-		return nil, errors.New("no input data provided")
-	}
-
-	// get the output
-	if args.Output == "" {
-		params.Out = nopWriteCloser{os.Stdout}
-	} else {
-		f, err := os.Create(args.Output)
-		check(err, "cannot create output file")
-		params.Out = f
-	}
-
-	return params, nil
-}
+// parameters is a package that handles the command line parameters
+package parameters
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/kpym/laxents/transformers"
+)
+
+// check is a helper function to check for errors
+func check(e error, msg string) {
+	if e != nil {
+		panic(fmt.Errorf("%s: %v", msg, e))
+	}
+}
+
+// nopWriteCloser is a WriteCloser that does nothing on Close
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// the parameters for the program
+type Args struct {
+	ToUnicode  bool     `arg:"-u,--to-unicode" help:"convert from LaTeX to Unicode"`
+	ToLatex    bool     `arg:"-l,--to-latex" help:"convert from Unicode to LaTeX"`
+	Input      string   `arg:"-i,--input" help:"input file"`
+	Output     string   `arg:"-o,--output" help:"output file"`
+	Verbatim   []string `arg:"--verbatim,separate" help:"additional verbatim-like environment (can be repeated)"`
+	Math       bool     `arg:"-m,--math" help:"also convert inside math mode"`
+	Symbols    bool     `arg:"-s,--symbols" help:"also convert the greek letters and the math symbols"`
+	EnsureMath bool     `arg:"--ensuremath" help:"wrap the symbols in text with \\ensuremath{} instead of $...$"`
+	Alphabets  bool     `arg:"-a,--alphabets" help:"also convert the math alphabets (\\mathbb{R}, \\mathcal{L}...)"`
+	Scripts    bool     `arg:"--scripts" help:"also convert the super/subscripts that have a Unicode form (x^{2}, a_{1}...)"`
+	Quotes     string   `arg:"--quotes" help:"also convert the quotes with the locale convention: english, german, french or polish"`
+	Dashes     bool     `arg:"--dashes" help:"also convert the dashes, the ellipsis and the special spaces (--, ---, \\ldots, ~, \\,, \\-)"`
+	Escape     bool     `arg:"--escape-specials" help:"the input is plain text, escape its special characters (& % $ # _ { } ~ ^ \\) when converting to LaTeX"`
+	Plain      bool     `arg:"--plain" help:"the output is plain text, unescape the special characters (\\&, \\%, \\textbackslash{}...) and remove the grouping braces when converting to Unicode"`
+	Braces     string   `arg:"--braces" default:"strip-accent-groups" help:"the braces groups policy when converting to Unicode: keep, strip-accent-groups or strip-all-redundant"`
+	Protect    bool     `arg:"--protect-case" help:"keep the BibTeX case protecting braces, like {NASA}, when converting to Unicode (always with --bib)"`
+	AccentArgs string   `arg:"--accent-args" default:"auto" help:"the style of the text accents arguments when converting to LaTeX: auto (\\'e, \\c{c}), braced (\\'{e}, \\c{c}) or unbraced (\\'e, \\c c)"`
+	CharGroups bool     `arg:"--char-groups" help:"write the accented characters in groups, like {\\'e}, when converting to LaTeX"`
+	Terminator string   `arg:"--terminator" default:"group" help:"the style of the end of the letter macros when converting to LaTeX: group ({\\ss}), empty (\\ss{}) or space (\\ss)"`
+	BibTeX     bool     `arg:"--bibtex" help:"write the accented characters in BibTeX special characters groups, like {\\\"{o}} or {\\ss}, when converting to LaTeX"`
+	Bib        bool     `arg:"--bib" help:"the input is a .bib file, convert only the title, author, editor, journal, booktitle, publisher, address and note fields"`
+	BibFields  []string `arg:"--bib-field,separate" help:"field to convert in the .bib file instead of the default ones (can be repeated)"`
+	Dotless    bool     `arg:"--keep-dotless" help:"keep the dotless i and j under the accents, like \\'\\i to ı́, when converting to Unicode"`
+	Fallback   string   `arg:"--fallback" default:"keep" help:"the way the characters without LaTeX form are written when converting to LaTeX: keep, symbol (\\symbol{\"014B}), char ({\\char\"014B}), hat (^^^^014b), unichar (\\unichar{331}) or error"`
+	CharCodes  string   `arg:"--char-codes" default:"all" help:"where the characters given by their code (\\char\"E9, \\symbol{\"E9}, ^^e9, \\accent\"7F e...) are decoded when converting to Unicode: all, text (only in the text, not in the math nor in the macro definitions) or none"`
+	Shorthands bool     `arg:"--shorthands" help:"convert the babel shorthands (\"a, \"s, \"-...) where german, ngerman, austrian, naustrian, swissgerman, nswissgerman, dutch or swedish is selected, when converting to Unicode"`
+	ShortLangs []string `arg:"--shorthands-lang,separate" help:"language whose babel shorthands are converted instead of the default ones (can be repeated)"`
+	Text       string   `arg:"positional" help:"string to convert"`
+}
+
+func (Args) Description() string {
+	return "convert between LaTeX accents and Unicode characters"
+}
+
+func (Args) Epilogue() string {
+	// get the executable name without the path
+	exe := filepath.Base(os.Args[0])
+	// remove the .exe extension on Windows
+	if filepath.Ext(exe) == ".exe" {
+		exe = exe[:len(exe)-4]
+	}
+	return fmt.Sprintf(`Examples:
+	%s -to-latex "déçû"
+	%s -to-unicode "d\\'e\\c{c}\\^{u}"
+	%s -to-unicode -i input.tex -o output.tex
+	%s -to-latex -i input.tex -o output.tex
+	%s -to-unicode --verbatim Verbatim -i input.tex
+	cat input.tex | %s -to-unicode
+	%s bib-dupes refs.bib
+	`, exe, exe, exe, exe, exe, exe, exe)
+}
+
+// PrintHelp prints the help message
+func PrintHelp() {
+	p := arg.MustParse(&Args{})
+	p.WriteHelp(os.Stdout)
+}
+
+// Parameters for the program
+// returned by the Get function
+type Parameters struct {
+	ToUnicode bool
+	Options   []transformers.Option
+	In        io.ReadCloser
+	Out       io.WriteCloser
+}
+
+var (
+	// the missing input error
+	ErrMissingParameters = errors.New("missing parameters")
+)
+
+// Get parses the command line arguments and returns the parameters
+func Get(osArgs []string) (params *Parameters, err error) {
+	// check if there are arguments
+	if len(osArgs) == 0 {
+		return nil, ErrMissingParameters
+	}
+
+	// capture the panic and return it as an error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	var args Args
+	params = &Parameters{}
+
+	p, err := arg.NewParser(arg.Config{}, &args)
+	check(err, "cannot create parser")
+	p.MustParse(osArgs)
+
+	// get the replacer
+	if args.ToUnicode && args.ToLatex {
+		return nil, errors.New("cannot specify both -to-unicode and -to-latex")
+	}
+	if !args.ToUnicode && !args.ToLatex {
+		return nil, errors.New("must specify either -to-unicode or -to-latex")
+	}
+	params.ToUnicode = args.ToUnicode
+	if args.ToUnicode && args.Escape {
+		return nil, errors.New("cannot specify -escape-specials with -to-unicode")
+	}
+	if args.ToLatex && args.Plain {
+		return nil, errors.New("cannot specify -plain with -to-latex")
+	}
+
+	// get the transformer options
+	if len(args.Verbatim) > 0 {
+		params.Options = append(params.Options, transformers.WithVerbatimEnvs(args.Verbatim...))
+	}
+	params.Options = append(params.Options, transformers.WithMath(args.Math))
+	params.Options = append(params.Options, transformers.WithSymbols(args.Symbols))
+	params.Options = append(params.Options, transformers.WithEnsureMath(args.EnsureMath))
+	params.Options = append(params.Options, transformers.WithMathAlphabets(args.Alphabets))
+	params.Options = append(params.Options, transformers.WithScripts(args.Scripts))
+	params.Options = append(params.Options, transformers.WithDashes(args.Dashes))
+	params.Options = append(params.Options, transformers.WithEscapeSpecials(args.Escape))
+	params.Options = append(params.Options, transformers.WithPlain(args.Plain))
+	switch q := transformers.Quotes(args.Quotes); q {
+	case transformers.NoQuotes, transformers.EnglishQuotes, transformers.GermanQuotes, transformers.FrenchQuotes, transformers.PolishQuotes:
+		params.Options = append(params.Options, transformers.WithQuotes(q))
+	default:
+		return nil, fmt.Errorf("unknown quotes convention %q", args.Quotes)
+	}
+
+	switch b := transformers.Braces(args.Braces); b {
+	case transformers.KeepBraces, transformers.StripAccentGroups, transformers.StripRedundantBraces:
+		params.Options = append(params.Options, transformers.WithBraces(b))
+	default:
+		return nil, fmt.Errorf("unknown braces policy %q", args.Braces)
+	}
+	params.Options = append(params.Options, transformers.WithProtectCase(args.Protect))
+	switch a := transformers.AccentArgs(args.AccentArgs); a {
+	case transformers.AutoAccentArgs, transformers.BracedAccentArgs, transformers.UnbracedAccentArgs:
+		params.Options = append(params.Options, transformers.WithAccentArgs(a))
+	default:
+		return nil, fmt.Errorf("unknown accent arguments style %q", args.AccentArgs)
+	}
+	params.Options = append(params.Options, transformers.WithCharGroups(args.CharGroups))
+	switch term := transformers.Terminator(args.Terminator); term {
+	case transformers.GroupTerminator, transformers.EmptyTerminator, transformers.SpaceTerminator:
+		params.Options = append(params.Options, transformers.WithTerminator(term))
+	default:
+		return nil, fmt.Errorf("unknown terminator style %q", args.Terminator)
+	}
+	params.Options = append(params.Options, transformers.WithBibTeX(args.BibTeX))
+	params.Options = append(params.Options, transformers.WithKeepDotless(args.Dotless))
+	switch c := transformers.CharCodes(args.CharCodes); c {
+	case transformers.AllCharCodes, transformers.TextCharCodes, transformers.NoCharCodes:
+		params.Options = append(params.Options, transformers.WithCharCodes(c))
+	default:
+		return nil, fmt.Errorf("unknown character codes policy %q", args.CharCodes)
+	}
+	switch f := transformers.Fallback(args.Fallback); f {
+	case transformers.KeepFallback, transformers.SymbolFallback, transformers.CharFallback,
+		transformers.HatFallback, transformers.UnicharFallback, transformers.ErrorFallback:
+		params.Options = append(params.Options, transformers.WithFallback(f))
+	default:
+		return nil, fmt.Errorf("unknown fallback %q", args.Fallback)
+	}
+	if args.Shorthands || len(args.ShortLangs) > 0 {
+		params.Options = append(params.Options, transformers.WithShorthands(args.ShortLangs...))
+	}
+	if args.Bib || len(args.BibFields) > 0 {
+		params.Options = append(params.Options, transformers.WithBibFields(args.BibFields...))
+	}
+
+	// get the input
+	if args.Input != "" && args.Text != "" {
+		return nil, errors.New("cannot specify both a file and a string")
+	}
+	if args.Input != "" {
+		f, err := os.Open(args.Input)
+		check(err, "cannot open input file")
+		params.In = f
+	} else if args.Text != "" {
+		params.In = io.NopCloser(strings.NewReader(args.Text))
+	} else {
+		// check if there is data on stdin
+		stat, _ := os.Stdin.Stat()
+		if (stat.Mode() & os.ModeCharDevice) == 0 {
+			params.In = io.NopCloser(os.Stdin)
+		}
+	}
+	if params.In == nil {
+		return nil, errors.New("no input data provided")
+	}
+
+	// get the output
+	if args.Output == "" {
+		params.Out = nopWriteCloser{os.Stdout}
+	} else {
+		f, err := os.Create(args.Output)
+		check(err, "cannot create output file")
+		params.Out = f
+	}
+
+	return params, nil
+}
@@ -0,0 +1,124 @@
+package transformers
+
+// mathAlphabet is a LaTeX math alphabet with the code points of its Unicode letters,
+// the first name is used to convert from Unicode to LaTeX.
+type mathAlphabet struct {
+	names []string
+	upper rune // the code point of A
+	lower rune // the code point of a
+	digit rune // the code point of 0 (0 if the alphabet has no digits)
+}
+
+// mathAlphabets are the Mathematical Alphanumeric Symbols (U+1D400–U+1D7FF)
+var mathAlphabets = []mathAlphabet{
+	{[]string{"mathbf"}, 0x1D400, 0x1D41A, 0x1D7CE},
+	{[]string{"mathit"}, 0x1D434, 0x1D44E, 0},
+	{[]string{"mathcal", "mathscr"}, 0x1D49C, 0x1D4B6, 0},
+	{[]string{"mathfrak"}, 0x1D504, 0x1D51E, 0},
+	{[]string{"mathbb"}, 0x1D538, 0x1D552, 0x1D7D8},
+	{[]string{"mathsf"}, 0x1D5A0, 0x1D5BA, 0x1D7E2},
+	{[]string{"mathtt"}, 0x1D670, 0x1D68A, 0x1D7F6},
+}
+
+// mathAlphabetHoles are the reserved code points of the Mathematical Alphanumeric Symbols
+// with the Letterlike Symbols that are used instead
+var mathAlphabetHoles = map[rune]rune{
+	// italic
+	0x1D455: 'ℎ',
+	// script
+	0x1D49D: 'ℬ',
+	0x1D4A0: 'ℰ',
+	0x1D4A1: 'ℱ',
+	0x1D4A3: 'ℋ',
+	0x1D4A4: 'ℐ',
+	0x1D4A7: 'ℒ',
+	0x1D4A8: 'ℳ',
+	0x1D4AD: 'ℛ',
+	0x1D4BA: 'ℯ',
+	0x1D4BC: 'ℊ',
+	0x1D4C4: 'ℴ',
+	// fraktur
+	0x1D506: 'ℭ',
+	0x1D50B: 'ℌ',
+	0x1D50C: 'ℑ',
+	0x1D515: 'ℜ',
+	0x1D51D: 'ℨ',
+	// double-struck
+	0x1D53A: 'ℂ',
+	0x1D53F: 'ℍ',
+	0x1D545: 'ℕ',
+	0x1D547: 'ℙ',
+	0x1D548: 'ℚ',
+	0x1D549: 'ℝ',
+	0x1D551: 'ℤ',
+}
+
+// letter returns the Unicode letter of the alphabet for the ASCII letter or digit c
+func (a mathAlphabet) letter(c rune) (rune, bool) {
+	var r rune
+	switch {
+	case 'A' <= c && c <= 'Z':
+		r = a.upper + c - 'A'
+	case 'a' <= c && c <= 'z':
+		r = a.lower + c - 'a'
+	case '0' <= c && c <= '9' && a.digit != 0:
+		r = a.digit + c - '0'
+	default:
+		return 0, false
+	}
+	if h, ok := mathAlphabetHoles[r]; ok {
+		return h, true
+	}
+	return r, true
+}
+
+// alphabetLetter is a letter of a math alphabet, like \mathbb{R}
+type alphabetLetter struct {
+	name   string // the LaTeX alphabet macro, like \mathbb
+	letter rune   // the ASCII letter or digit
+}
+
+// latexMathAlphabets is the LaTeX to Unicode mapping of the math alphabets,
+// the alphabet is identified by the code point of its A
+var latexMathAlphabets = alphabetsByName(mathAlphabets)
+
+// mathAlphabetsByUpper are the math alphabets by the code point of their A
+var mathAlphabetsByUpper = alphabetsByUpper(mathAlphabets)
+
+// unicodeMathAlphabetsToLaTeX is the Unicode to LaTeX mapping of the math alphabets letters
+var unicodeMathAlphabetsToLaTeX = alphabetsByRune(mathAlphabets)
+
+// alphabetsByName returns the LaTeX to Unicode mapping of the alphabets
+func alphabetsByName(alphabets []mathAlphabet) map[string]latexSpecial {
+	m := make(map[string]latexSpecial)
+	for _, a := range alphabets {
+		for _, name := range a.names {
+			m[name] = latexSpecial{latexSpecialAlphabet, a.upper}
+		}
+	}
+	return m
+}
+
+// alphabetsByUpper returns the alphabets by the code point of their A
+func alphabetsByUpper(alphabets []mathAlphabet) map[rune]mathAlphabet {
+	m := make(map[rune]mathAlphabet)
+	for _, a := range alphabets {
+		m[a.upper] = a
+	}
+	return m
+}
+
+// alphabetsByRune returns the Unicode to LaTeX mapping of the alphabets letters (using their first name)
+func alphabetsByRune(alphabets []mathAlphabet) map[rune]alphabetLetter {
+	m := make(map[rune]alphabetLetter)
+	for _, a := range alphabets {
+		for _, chars := range []string{"AZ", "az", "09"} {
+			for c := rune(chars[0]); c <= rune(chars[1]); c++ {
+				if r, ok := a.letter(c); ok {
+					m[r] = alphabetLetter{"\\" + a.names[0], c}
+				}
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,34 @@
+package transformers
+
+import "testing"
+
+func TestMathAlphabetLetter(t *testing.T) {
+	data := []struct {
+		name string // the alphabet name
+		c    rune   // the ASCII letter or digit
+		exp  rune   // expected letter
+		ok   bool   // expected ok
+	}{
+		{"mathbb", 'R', 'ℝ', true},
+		{"mathbb", 'A', '𝔸', true},
+		{"mathbb", 'k', '𝕜', true},
+		{"mathbb", '1', '𝟙', true},
+		{"mathcal", 'L', 'ℒ', true},
+		{"mathscr", 'A', '𝒜', true},
+		{"mathfrak", 'g', '𝔤', true},
+		{"mathfrak", 'Z', 'ℨ', true},
+		{"mathbf", 'x', '𝐱', true},
+		{"mathit", 'h', 'ℎ', true},
+		{"mathtt", '0', '𝟶', true},
+		{"mathcal", '1', 0, false},
+		{"mathbb", '+', 0, false},
+	}
+
+	for i, d := range data {
+		a := mathAlphabetsByUpper[latexMathAlphabets[d.name].utf8]
+		r, ok := a.letter(d.c)
+		if r != d.exp || ok != d.ok {
+			t.Errorf("test %d: expected %q, %v, got %q, %v", i, d.exp, d.ok, r, ok)
+		}
+	}
+}
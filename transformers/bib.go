@@ -0,0 +1,248 @@
+package transformers
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/kpym/laxents/internal/bibtex"
+	"golang.org/x/text/transform"
+)
+
+// defaultBibFields are the fields of the .bib entries that are converted by default
+var defaultBibFields = []string{
+	"title",
+	"author",
+	"editor",
+	"journal",
+	"booktitle",
+	"publisher",
+	"address",
+	"note",
+}
+
+// rawBibEntries are the entries whose content is passed through untouched
+var rawBibEntries = []string{"comment", "preamble"}
+
+// bibState is the position of the parser in the .bib source
+type bibState int
+
+const (
+	bibJunk   bibState = iota // outside the entries, passed through untouched
+	bibType                   // after the `@`, the entry type and its opening delimiter
+	bibRaw                    // the content of @preamble and @comment, passed through untouched
+	bibKey                    // the citation key
+	bibField                  // before the name of a field
+	bibValue                  // in the value of a field, between its parts
+	bibBraced                 // the content of a {...} part of a value
+	bibQuoted                 // the content of a "..." part of a value
+)
+
+// bibFields is a transformer that parses a .bib source
+// and applies inner only to the content of the values of the selected fields
+// and of the @string abbreviations, like @string{jgr = "J. G{\"o}del"}, that are text.
+// The keys, the other fields and everything outside the entries are passed through untouched.
+type bibFields struct {
+	inner   transform.Transformer
+	fields  []string // the (lowercase) names of the fields to convert
+	state   bibState
+	closer  byte // the closing delimiter of the current entry, `}` or `)`
+	depth   int  // the brace depth inside the current value part (or raw entry)
+	convert bool // the current field is converted
+	abbrev  bool // the current entry is a @string, whose fields are all converted
+}
+
+// newBibFields returns a transformer that applies inner to the selected fields of a .bib source
+func newBibFields(inner transform.Transformer, o *options) transform.Transformer {
+	fields := make([]string, len(o.bibFields))
+	for i, f := range o.bibFields {
+		fields[i] = strings.ToLower(f)
+	}
+	return &bibFields{inner: inner, fields: fields}
+}
+
+// Reset resets the transformer
+func (t *bibFields) Reset() {
+	t.state = bibJunk
+	t.depth = 0
+	t.convert = false
+	t.abbrev = false
+	t.inner.Reset()
+}
+
+// Transform converts the selected fields of the .bib entries with the inner transformer
+func (t *bibFields) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		rest := src[nSrc:]
+		switch t.state {
+		case bibJunk:
+			n := bytes.IndexByte(rest, '@') + 1
+			if n == 0 {
+				n = len(rest)
+			} else {
+				t.state = bibType
+			}
+			if !write(dst, rest[:n], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc += n
+		case bibType:
+			// the type and the opening delimiter are processed at once, like `article {`
+			name, needMore := bibtex.Name(rest)
+			n := len(name)
+			n += bibtex.SkipSpaces(rest[n:])
+			if (needMore || n == len(rest)) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			t.state = bibJunk
+			if n < len(rest) && (rest[n] == '{' || rest[n] == '(') {
+				t.closer = '}'
+				if rest[n] == '(' {
+					t.closer = ')'
+				}
+				t.state = bibKey
+				t.abbrev = strings.EqualFold(name, "string")
+				switch {
+				case slices.Contains(rawBibEntries, strings.ToLower(name)):
+					t.state = bibRaw
+					t.depth = 0
+				case t.abbrev:
+					// the @string entry has no key, like @string{jgr = "..."}
+					t.state = bibField
+				}
+				n++
+			}
+			if !write(dst, rest[:n], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc += n
+		case bibRaw:
+			n := 0
+			for n < len(rest) && t.state == bibRaw {
+				switch c := rest[n]; {
+				case c == t.closer && t.depth == 0:
+					t.state = bibJunk
+				case c == '{':
+					t.depth++
+				case c == '}':
+					t.depth--
+				}
+				n++
+			}
+			if !write(dst, rest[:n], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc += n
+		case bibKey:
+			n := bytes.IndexAny(rest, ","+string(t.closer)) + 1
+			switch {
+			case n == 0:
+				n = len(rest)
+			case rest[n-1] == ',':
+				t.state = bibField
+			default:
+				t.state = bibJunk
+			}
+			if !write(dst, rest[:n], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc += n
+		case bibField:
+			n := bibtex.SkipSpaces(rest)
+			if n == 0 {
+				switch rest[0] {
+				case ',':
+					n = 1
+				case t.closer:
+					n = 1
+					t.state = bibJunk
+				default:
+					// the field name and the `=` are processed at once, like `title =`
+					name, needMore := bibtex.Name(rest)
+					n = len(name)
+					n += bibtex.SkipSpaces(rest[n:])
+					if (needMore || n == len(rest)) && !atEOF {
+						return nDst, nSrc, transform.ErrShortSrc
+					}
+					if n < len(rest) && rest[n] == '=' {
+						t.convert = t.abbrev || slices.Contains(t.fields, strings.ToLower(name))
+						t.state = bibValue
+						n++
+					}
+					// a malformed field is passed through
+					n = max(n, 1)
+				}
+			}
+			if !write(dst, rest[:n], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc += n
+		case bibValue:
+			n := bibtex.SkipSpaces(rest)
+			if n == 0 {
+				n = 1
+				switch rest[0] {
+				case '{':
+					t.state = bibBraced
+					t.depth = 0
+				case '"':
+					t.state = bibQuoted
+					t.depth = 0
+				case ',':
+					t.state = bibField
+				case t.closer:
+					t.state = bibJunk
+				case '#':
+				default:
+					// a number or a @string abbreviation
+					name, _ := bibtex.Name(rest)
+					n = max(len(name), 1)
+				}
+			}
+			if !write(dst, rest[:n], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc += n
+		case bibBraced, bibQuoted:
+			end, depth := bibtex.ValueEnd(rest, t.depth, t.state == bibQuoted)
+			found := end >= 0
+			if !found {
+				end = len(rest)
+			}
+			if !t.convert {
+				k := copy(dst[nDst:], rest[:end])
+				nDst += k
+				nSrc += k
+				if k < end {
+					_, t.depth = bibtex.ValueEnd(rest[:k], t.depth, t.state == bibQuoted)
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				t.depth = depth
+			} else {
+				m, k, err := t.inner.Transform(dst[nDst:], rest[:end], atEOF || found)
+				nDst += m
+				nSrc += k
+				_, t.depth = bibtex.ValueEnd(rest[:k], t.depth, t.state == bibQuoted)
+				if err != nil {
+					return nDst, nSrc, err
+				}
+				if k < end {
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+			}
+			if !found {
+				continue
+			}
+			// copy the closing delimiter of the part
+			if !writeByte(dst, rest[end], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc++
+			if t.convert {
+				t.inner.Reset()
+			}
+			t.state = bibValue
+		}
+	}
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,51 @@
+package transformers
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestBibFields(t *testing.T) {
+	data := []struct {
+		src    string   // source string
+		fields []string // the converted fields (the default ones if nil)
+		exp    string   // expected result
+	}{
+		{"@article{k, title = {\\'Ecole}}", nil, "@article{k, title = {École}}"},
+		{"@article{k,\n  Title = \"G{\\\"o}del\" # {\\'e},\n}", nil, "@article{k,\n  Title = \"Gödel\" # {é},\n}"},
+		{"@article{k\\'e, url = {\\'e}, doi = \"\\'e\", year = 2\\'e}", nil, "@article{k\\'e, url = {\\'e}, doi = \"\\'e\", year = 2\\'e}"},
+		{"@book(k, title = {\\'e}, note = {a {\\'e}} )", nil, "@book(k, title = {é}, note = {a é} )"},
+		{"@string{s = {\\'e}} @comment{\\'e} @preamble{\"\\'e\"}", nil, "@string{s = {é}} @comment{\\'e} @preamble{\"\\'e\"}"},
+		{"@STRING(jgr = \"J. G{\\\"o}del\" # {\\'e}, pub = s # {\\'e})\n@misc{k, url = {\\'e}}", nil, "@STRING(jgr = \"J. Gödel\" # {é}, pub = s # {é})\n@misc{k, url = {\\'e}}"},
+		{"% \\'e @ \\'e\n@misc{k, author = s # {\\'e}}", nil, "% \\'e @ \\'e\n@misc{k, author = s # {é}}"},
+		{"@misc{k, title = {\\'e $\\'e$}, url = {\\'e}}", nil, "@misc{k, title = {é $\\'e$}, url = {\\'e}}"},
+		{"@article{k, title = {\\\"Uber {\\\"U} {\\'e} {\\'E}cole}}", nil, "@article{k, title = {Über {Ü} é {É}cole}}"},
+		{"@misc{k, title = {\\'e}, url = {\\'e}}", []string{"URL"}, "@misc{k, title = {\\'e}, url = {é}}"},
+	}
+
+	for i, d := range data {
+		tr := transform.Chain(ToUnicodeAccents(WithBibFields(d.fields...)), norm.NFC)
+		got, _, err := transform.String(tr, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		tr.Reset()
+		r := transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), tr)
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package transformers
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// Braces is the policy for the braces groups when converting to Unicode
+type Braces string
+
+const (
+	KeepBraces           Braces = "keep"                // the braces are kept, like {\'e} to {é}
+	StripAccentGroups    Braces = "strip-accent-groups" // the braces around a converted letter are removed, like {\'e} to é
+	StripRedundantBraces Braces = "strip-all-redundant" // also the braces that only group, like {Foo} to Foo
+)
+
+// maxGroup is the maximal length of a group whose braces can be removed by braceGroups
+const maxGroup = 256
+
+// braceGroups is a transformer that removes the braces that only group,
+// keeping the braces of the macros arguments, like \emph{...}.
+// If plain is true, it also unescapes the LaTeX special characters, like \& or \textbackslash{},
+// and removes the groups with macros, like {\em ...}.
+type braceGroups struct {
+	plain       bool     // the output is plain text
+	protectCase bool     // keep the groups with uppercase letters, like {NASA}
+	stack       []bool   // the open braces, true if the brace only groups (and is removed)
+	arg         macroArg // if the next brace opens the argument of a macro
+	mode        texMode
+	comment     bool
+}
+
+// Reset resets the transformer
+func (t *braceGroups) Reset() {
+	t.stack = t.stack[:0]
+	t.arg = noMacroArg
+}
+
+// setMode sets the TeX mode of the text to transform
+func (t *braceGroups) setMode(m texMode) {
+	t.mode = m
+}
+
+// setComment sets if the text to transform is a comment
+func (t *braceGroups) setComment(c bool) {
+	t.comment = c
+}
+
+// Transform removes the grouping braces (and unescapes the special characters in plain mode)
+func (t *braceGroups) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if t.mode == mathMode || t.comment {
+		n := copy(dst, src)
+		if n < len(src) {
+			return n, n, transform.ErrShortDst
+		}
+		return n, n, nil
+	}
+	for nSrc < len(src) {
+		c := src[nSrc]
+		switch {
+		case c == '\\':
+			name, n, needMore := getMacroName(src[nSrc+1:])
+			if needMore && !atEOF {
+				// we need more data to know the macro
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			r, ok := latexSpecialsToUnicode[name]
+			if !ok || !t.plain {
+				// not a special character, copy the macro
+				if !write(dst, src[nSrc:nSrc+1+n], &nDst) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				t.arg = afterEscape.nextBytes(src[nSrc+1 : nSrc+1+n])
+				nSrc += 1 + n
+				continue
+			}
+			if isMacroLetter(name[0]) {
+				// gobble the empty group or the space after the control word, like \textbackslash{}
+				m, needMore := gobbleEmptyGroup(src[nSrc+1+n:])
+				if needMore && !atEOF {
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				n += m
+			}
+			if !writeRune(dst, r, &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			t.arg = noMacroArg
+			nSrc += 1 + n
+		case c == '{':
+			group := !t.arg.opensArg()
+			if group {
+				if nSrc+1 == len(src) && !atEOF {
+					// we need the next byte to know if the group is empty
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				// an empty group ends a control word or breaks a ligature, like in --{}--
+				group = nSrc+1 < len(src) && src[nSrc+1] != '}'
+			}
+			if group && (!t.plain || t.protectCase) {
+				keep, needMore := t.keepGroup(src[nSrc+1:])
+				if needMore && !atEOF {
+					// we need the whole group to know if it is redundant
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				group = !keep
+			}
+			if !group && !writeByte(dst, c, &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			t.stack = append(t.stack, group)
+			t.arg = noMacroArg
+			nSrc++
+		case c == '}' && len(t.stack) > 0:
+			group := t.stack[len(t.stack)-1]
+			if !group && !writeByte(dst, c, &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			t.stack = t.stack[:len(t.stack)-1]
+			// the next brace can be an other argument, like in \href{...}{...}
+			t.arg = noMacroArg
+			if !group {
+				t.arg = afterMacro
+			}
+			nSrc++
+		default:
+			if !writeByte(dst, c, &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			t.arg = t.arg.next(c)
+			nSrc++
+		}
+	}
+	return nDst, nSrc, nil
+}
+
+// keepGroup returns true if the group starting in src (after the `{`) should keep its braces:
+// if it contains a macro (not in plain mode), a macro parameter, like {#1}, an uppercase letter (if the case is protected),
+// or if it is too long.
+// It returns needMore if src does not contain the whole group.
+func (t *braceGroups) keepGroup(src []byte) (keep, needMore bool) {
+	depth := 0
+	for i := 0; i < len(src); {
+		if i >= maxGroup {
+			return true, false
+		}
+		switch src[i] {
+		case '\\':
+			name, n, more := getMacroName(src[i+1:])
+			if more {
+				return true, true
+			}
+			if isMacroLetter(name[0]) && !t.plain {
+				// the macros can be declarations, like {\em ...}
+				return true, false
+			}
+			i += 1 + n
+			continue
+		case '#':
+			// the groups of the definitions bodies, like {#1}, are not redundant
+			return true, false
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return false, false
+			}
+			depth--
+		default:
+			r, size := utf8.DecodeRune(src[i:])
+			if t.protectCase && unicode.IsUpper(r) {
+				// the case protecting braces of BibTeX, like {NASA}
+				return true, false
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	return true, true
+}
+
+// isMacroLetter returns true if c can be part of the name of a control word, like \foo@bar
+func isMacroLetter(c byte) bool {
+	return isLatin(c) || c == '@'
+}
+
+// getMacroName returns the name of the macro at the beginning of src (after the `\`)
+// and the number of bytes read.
+// It returns needMore if src is too short to know the whole name.
+func getMacroName(src []byte) (name string, n int, needMore bool) {
+	if len(src) == 0 {
+		return "", 0, true
+	}
+	if !isMacroLetter(src[0]) {
+		return string(src[0]), 1, false
+	}
+	for n < len(src) && isMacroLetter(src[n]) {
+		n++
+	}
+	return string(src[:n]), n, n == len(src)
+}
+
+// macroArg is the state of the LaTeX source that tells if a `{` opens the argument of a macro,
+// like in \emph{...}, \section*{...}, \textbf {...}, \cite[p.~3]{...}, \href{...}{...},
+// x^{12}, \def\x#1{...} or \hbox to 3pt{...}
+type macroArg uint8
+
+const (
+	noMacroArg   macroArg = iota // a `{` opens a group
+	afterEscape                  // after a `\`
+	inMacroName                  // in the name of a control word
+	afterSpaces                  // after the spaces that follow a control word
+	afterMacro                   // after the star of a control word, an optional argument, an argument, ^ or _
+	afterHash                    // after the # of a macro parameter
+	inParams                     // in the parameter text of a definition, like #1#2 or #1.
+	inKeyword                    // in a keyword after a control word, like the to of \hbox to 3pt
+	afterKeyword                 // after the spaces that follow the keyword
+	inDimension                  // in the dimension after the keyword, like the 3pt of \hbox to 3pt
+)
+
+// isTeXSpace returns true if c is a space skipped after a control word
+func isTeXSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// isDigit returns true if c is a decimal digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// next returns the state after the byte c
+func (a macroArg) next(c byte) macroArg {
+	switch {
+	case c == '\\' && a != afterEscape:
+		return afterEscape
+	case isMacroLetter(c) && (a == afterEscape || a == inMacroName):
+		return inMacroName
+	case a == afterEscape:
+		// a control symbol, like \# or \^
+		return noMacroArg
+	case isTeXSpace(c) && (a == inMacroName || a == afterSpaces):
+		// the spaces after a control word are skipped by TeX, like in \textbf {...}
+		return afterSpaces
+	case c == '*' && a == inMacroName:
+		return afterMacro
+	case c == '}' || c == ']':
+		// the optional arguments are followed by the mandatory ones, like \cite[p.~3]{key}
+		return afterMacro
+	case c == '^' || c == '_':
+		// the group of the superscript or subscript, like x^{12}, is its argument
+		return afterMacro
+	case c == '#':
+		return afterHash
+	case isDigit(c) && a == afterHash, a == inParams:
+		// the parameter text, with its delimiters, is followed by the body, like \def\x#1.{...}
+		return inParams
+	case isLatin(c) && (a == afterSpaces || a == inKeyword):
+		return inKeyword
+	case isTeXSpace(c) && (a == inKeyword || a == afterKeyword):
+		return afterKeyword
+	case (isDigit(c) || c == '.' || c == '-' || c == '+') && a == afterKeyword,
+		(isLatin(c) || isDigit(c) || c == '.' || isTeXSpace(c)) && a == inDimension:
+		// the dimension of a box, like \hbox to 3pt{...} or \vbox spread -1.5 em{...}
+		return inDimension
+	}
+	return noMacroArg
+}
+
+// nextBytes returns the state after the bytes of s
+func (a macroArg) nextBytes(s []byte) macroArg {
+	for _, c := range s {
+		a = a.next(c)
+	}
+	return a
+}
+
+// opensArg returns true if a `{` opens the argument of a macro
+func (a macroArg) opensArg() bool {
+	return a == inMacroName || a == afterSpaces || a == afterMacro || a == inParams || a == inDimension
+}
+
+// gobbleEmptyGroup returns the number of bytes of the empty group, or the space, at the beginning of src.
+// It returns needMore if src is too short to know.
+func gobbleEmptyGroup(src []byte) (n int, needMore bool) {
+	switch {
+	case len(src) == 0:
+		return 0, true
+	case src[0] == ' ':
+		return 1, false
+	case src[0] != '{':
+		return 0, false
+	case len(src) == 1:
+		return 0, true
+	case src[1] == '}':
+		return 2, false
+	}
+	return 0, false
+}
@@ -0,0 +1,178 @@
+package transformers
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+)
+
+func TestGetMacroName(t *testing.T) {
+	data := []struct {
+		src     string
+		expName string
+		expn    int
+		expMore bool
+	}{
+		{"", "", 0, true},
+		{"&x", "&", 1, false},
+		{"emph{", "emph", 4, false},
+		{"emph", "emph", 4, true},
+		{"\\", "\\", 1, false},
+		{"foo@bar{", "foo@bar", 7, false},
+		{"@namedef", "@namedef", 8, true},
+	}
+
+	for i, d := range data {
+		name, n, more := getMacroName([]byte(d.src))
+		if name != d.expName {
+			t.Errorf("test %d: expected name=%q, got name=%q", i, d.expName, name)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGobbleEmptyGroup(t *testing.T) {
+	data := []struct {
+		src     string
+		expn    int
+		expMore bool
+	}{
+		{"", 0, true},
+		{"{", 0, true},
+		{"{}x", 2, false},
+		{" x", 1, false},
+		{"{x}", 0, false},
+		{"x", 0, false},
+	}
+
+	for i, d := range data {
+		n, more := gobbleEmptyGroup([]byte(d.src))
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestBraceGroups_Plain(t *testing.T) {
+	data := []struct {
+		src string // source string
+		exp string // expected string
+	}{
+		{"R\\&D 50\\% \\_draft\\_ \\#1 \\$5", "R&D 50% _draft_ #1 $5"},
+		{"\\{x\\}", "{x}"},
+		{"a\\textbackslash{}b \\textbackslash c", "a\\b \\c"},
+		{"\\textasciitilde{}\\textasciicircum{}", "~^"},
+		{"{Foo} {{Bar}}", "Foo Bar"},
+		{"\\emph{Foo} \\textbf{{Bar}}", "\\emph{Foo} \\textbf{Bar}"},
+		{"\\href{a}{b} {c}", "\\href{a}{b} c"},
+		{"\\cite[p.~3]{key}", "\\cite[p.~3]{key}"},
+		{"\\\\{x}", "\\\\x"},
+		{"x}", "x}"},
+		{"\\section*{Intro} \\hspace*{1cm} \\textbf {xy}", "\\section*{Intro} \\hspace*{1cm} \\textbf {xy}"},
+		{"\\@namedef{x}{y} \\foo@bar{x}", "\\@namedef{x}{y} \\foo@bar{x}"},
+		{"--{}-- {}", "--{}-- {}"},
+	}
+
+	for i, d := range data {
+		got, _, err := transform.String(&braceGroups{plain: true}, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		r := transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), &braceGroups{plain: true})
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+func TestBraceGroups(t *testing.T) {
+	data := []struct {
+		protect bool   // protect the case
+		src     string // source string
+		exp     string // expected string
+	}{
+		{false, "{Foo} {{bar}} {}", "Foo bar {}"},
+		{false, "\\section*{Introduction} \\hspace*{1cm}", "\\section*{Introduction} \\hspace*{1cm}"},
+		{false, "\\@namedef{x}{y} \\foo@bar{x} {z}", "\\@namedef{x}{y} \\foo@bar{x} z"},
+		{false, "\\textbf {xy} \\textbf\n{xy} \\href{a}{b} {c}", "\\textbf {xy} \\textbf\n{xy} \\href{a}{b} c"},
+		{false, "--{}-- \\\\ {x}", "--{}-- \\\\ x"},
+		{false, "{\\em x} \\emph{x} \\LaTeX{}", "{\\em x} \\emph{x} \\LaTeX{}"},
+		{false, "\\& {\\&}", "\\& \\&"},
+		{false, "\\def\\y#1{{#1}} \\def\\z#1.#2\\par{ab} \\def\\w#1.{ab}", "\\def\\y#1{{#1}} \\def\\z#1.#2\\par{ab} \\def\\w#1.{ab}"},
+		{false, "\\newcommand{\\sq}{x^{12}} \\ensuremath{a_{ij}} ^ {x}", "\\newcommand{\\sq}{x^{12}} \\ensuremath{a_{ij}} ^ x"},
+		{false, "\\hbox to 3pt{x} \\vbox spread -1.5 em{y} \\hbox to\\hsize{z}", "\\hbox to 3pt{x} \\vbox spread -1.5 em{y} \\hbox to\\hsize{z}"},
+		{false, "\\it foo{x} \\#1{y} 3pt{z}", "\\it foox \\#1y 3ptz"},
+		{true, "{NASA} {iPhone} {the}", "{NASA} {iPhone} the"},
+		{true, "{\\em x} {\\Large x}", "{\\em x} {\\Large x}"},
+	}
+
+	for i, d := range data {
+		got, _, err := transform.String(&braceGroups{protectCase: d.protect}, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		r := transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), &braceGroups{protectCase: d.protect})
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+func TestKeepGroup(t *testing.T) {
+	long := strings.Repeat("x", maxGroup) + "}"
+	data := []struct {
+		plain, protect bool
+		src            string
+		expKeep        bool
+		expMore        bool
+	}{
+		{false, false, "foo}", false, false},
+		{false, false, "a {b} c}", false, false},
+		{false, false, "\\em x}", true, false},
+		{true, false, "\\em x}", false, false},
+		{false, false, "\\& x}", false, false},
+		{false, false, "#1}", true, false},
+		{false, true, "NASA}", true, false},
+		{false, true, "\\Large x}", true, false},
+		{false, false, "foo", true, true},
+		{false, false, long, true, false},
+	}
+
+	for i, d := range data {
+		bg := &braceGroups{plain: d.plain, protectCase: d.protect}
+		keep, more := bg.keepGroup([]byte(d.src))
+		if keep != d.expKeep {
+			t.Errorf("test %d: expected keep=%v, got keep=%v", i, d.expKeep, keep)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package transformers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharCodes is the policy for the characters given by their code, like \char"E9 or ^^e9, when converting to Unicode
+type CharCodes string
+
+const (
+	AllCharCodes  CharCodes = "all"  // the codes are decoded in the text and in the math
+	TextCharCodes CharCodes = "text" // the codes are decoded only in the text, not in the math nor in the macro definitions
+	NoCharCodes   CharCodes = "none" // the codes are not decoded
+)
+
+// latexCharCodes are the TeX primitives (and macros) that give a character or an accent by its code
+var latexCharCodes = map[string]latexSpecial{
+	"char":    {latexSpecialCharCode, 0},   // \char"E9, \char'351 or \char233
+	"symbol":  {latexSpecialCharCode, 0},   // \symbol{"E9}
+	"unichar": {latexSpecialCharCode, 0},   // \unichar{233}
+	"accent":  {latexSpecialAccentCode, 0}, // \accent"7F e, with the slot of the accent in the font
+}
+
+// accentSlots are the diacritics by their slot in the OT1 and T1 fonts, used by \accent
+var accentSlots = map[rune]rune{
+	// T1
+	0x00: 0x300, // grave
+	0x01: 0x301, // acute
+	0x02: 0x302, // circumflex
+	0x03: 0x303, // tilde
+	0x04: 0x308, // diaeresis
+	0x05: 0x30B, // double acute
+	0x06: 0x30A, // ring
+	0x07: 0x30C, // caron
+	0x08: 0x306, // breve
+	0x09: 0x304, // macron
+	0x0A: 0x307, // dot above
+	0x0B: 0x327, // cedilla
+	0x0C: 0x328, // ogonek
+	// OT1
+	0x12: 0x300, // grave
+	0x13: 0x301, // acute
+	0x14: 0x30C, // caron
+	0x15: 0x306, // breve
+	0x16: 0x304, // macron
+	0x17: 0x30A, // ring
+	0x18: 0x327, // cedilla
+	0x5E: 0x302, // circumflex
+	0x5F: 0x307, // dot above
+	0x7D: 0x30B, // double acute
+	0x7E: 0x303, // tilde
+	0x7F: 0x308, // diaeresis
+}
+
+// decodeCodes returns true if the characters given by their code are decoded in the current mode
+func (t *toUnicodeAccents) decodeCodes() bool {
+	return t.charCodes == AllCharCodes || (t.charCodes == TextCharCodes && t.mode == textMode && !t.definition)
+}
+
+// isCode returns true if r can be written for its code: a letter, a digit or a non-ASCII visible character,
+// but not the ASCII specials, like \ or {, that would change the meaning of the LaTeX source
+func isCode(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isLatin(r) || ('0' <= r && r <= '9')
+	}
+	return unicode.IsGraphic(r)
+}
+
+// getTeXNumber returns the value of the TeX number at the beginning of src, like "E9, '351 or 233,
+// and the number of bytes read, including the optional space after it.
+// It returns 0 bytes if src does not start with a number, and needMore if src is too short to know
+// (with the number read so far, as we can be at the end of the file).
+func getTeXNumber(src []byte) (v rune, n int, needMore bool) {
+	if len(src) == 0 {
+		return 0, 0, true
+	}
+	base, digits := rune(10), "0123456789"
+	switch src[0] {
+	case '"':
+		base, digits, n = 16, "0123456789ABCDEF", 1
+	case '\'':
+		base, digits, n = 8, "01234567", 1
+	}
+	start := n
+	for ; n < len(src); n++ {
+		d := strings.IndexByte(digits, src[n])
+		if d < 0 {
+			break
+		}
+		if v <= unicode.MaxRune {
+			v = v*base + rune(d)
+		}
+	}
+	if n == start || v > unicode.MaxRune {
+		return 0, 0, n == len(src) && n == start
+	}
+	if n == len(src) {
+		// the number (or the space after it) can continue, but we can be at the end of the file
+		return v, n, true
+	}
+	if src[n] == ' ' {
+		n++
+	}
+	return v, n, false
+}
+
+// getCharCode returns the code at the beginning of src, possibly braced, like "E9 or {"E9},
+// and the number of bytes read.
+// It returns 0 bytes if src does not start with a code, and needMore if src is too short to know.
+func getCharCode(src []byte) (v rune, n int, needMore bool) {
+	if len(src) == 0 || src[0] != '{' {
+		return getTeXNumber(src)
+	}
+	v, n, needMore = getTeXNumber(src[1:])
+	switch {
+	case needMore:
+		// the closing brace is needed
+		return 0, 0, true
+	case n == 0:
+		return 0, 0, false
+	case 1+n == len(src):
+		return 0, 0, true
+	case src[1+n] != '}':
+		return 0, 0, false
+	}
+	return v, n + 2, false
+}
+
+// isLowerHex returns true if c is a hexadecimal digit of the ^^ notation
+func isLowerHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f')
+}
+
+// getHatCode returns the character of the ^^ notation at the beginning of src, like ^^e9 or ^^^^00e9,
+// and the number of bytes read.
+// It returns 0 bytes if src does not start with this notation, and needMore if src is too short to know.
+func getHatCode(src []byte) (r rune, n int, needMore bool) {
+	// the longest notation first: ^^^^^^ followed by 6 digits, ^^^^ followed by 4, and ^^ followed by 2
+	for _, k := range []int{6, 4, 2} {
+		matches := true
+		for i := 0; i < 2*k && i < len(src) && matches; i++ {
+			matches = (i < k && src[i] == '^') || (i >= k && isLowerHex(src[i]))
+		}
+		if !matches {
+			continue
+		}
+		if len(src) < 2*k {
+			return 0, 0, true
+		}
+		for _, c := range src[k : 2*k] {
+			d := rune(strings.IndexByte("0123456789abcdef", c))
+			r = r*16 + d
+		}
+		if r > unicode.MaxRune {
+			return 0, 0, false
+		}
+		return r, 2 * k, false
+	}
+	return 0, 0, false
+}
+
+// getCodeLetter checks if the beginning of src is a braced character given by its code, like {\char"E9} or {^^e9},
+// the argument of an accent.
+// If it is the case, it returns the character and the number of bytes read.
+// It returns needMore if src is too short to know.
+func getCodeLetter(src []byte) (l rune, n int, needMore bool) {
+	if len(src) < 2 {
+		return 0, 0, len(src) == 0 || src[0] == '{'
+	}
+	if src[0] != '{' {
+		return 0, 0, false
+	}
+	switch src[1] {
+	case '\\':
+		ls, k, more := getSpecial(src[2:])
+		if more {
+			return 0, 0, true
+		}
+		if ls.spType != latexSpecialCharCode {
+			return 0, 0, false
+		}
+		n = 2 + k
+		l, k, more = getCharCode(src[n:])
+		if more {
+			return 0, 0, true
+		}
+		n += k
+	case '^':
+		var more bool
+		l, n, more = getHatCode(src[1:])
+		if more {
+			return 0, 0, true
+		}
+		n++
+	}
+	switch {
+	case n == 0 || !isCode(l):
+		return 0, 0, false
+	case n == len(src):
+		return 0, 0, true
+	case src[n] != '}':
+		return 0, 0, false
+	}
+	return l, n + 1, false
+}
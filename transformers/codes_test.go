@@ -0,0 +1,136 @@
+package transformers
+
+import "testing"
+
+func TestGetTeXNumber(t *testing.T) {
+	data := []struct {
+		src     string
+		expv    rune
+		expn    int
+		expMore bool
+	}{
+		{"", 0, 0, true},
+		{"\"E9}", 0xE9, 3, false},
+		{"\"E9 x", 0xE9, 4, false},
+		{"\"E9", 0xE9, 3, true},
+		{"\"", 0, 0, true},
+		{"\"e9}", 0, 0, false},
+		{"'351}", 0xE9, 4, false},
+		{"233  ", 233, 4, false},
+		{"\"}", 0, 0, false},
+		{"x", 0, 0, false},
+		{"\"FFFFFFFF ", 0, 0, false},
+		{"\"FFFFFFFF", 0, 0, false},
+	}
+
+	for i, d := range data {
+		v, n, more := getTeXNumber([]byte(d.src))
+		if v != d.expv {
+			t.Errorf("test %d: expected v=%d, got v=%d", i, d.expv, v)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGetCharCode(t *testing.T) {
+	data := []struct {
+		src     string
+		expv    rune
+		expn    int
+		expMore bool
+	}{
+		{"", 0, 0, true},
+		{"{", 0, 0, true},
+		{"{\"E9}x", 0xE9, 5, false},
+		{"{233", 0, 0, true},
+		{"{233 ", 0, 0, true},
+		{"{233 x}", 0, 0, false},
+		{"{x}", 0, 0, false},
+		{"\"E9 x", 0xE9, 4, false},
+	}
+
+	for i, d := range data {
+		v, n, more := getCharCode([]byte(d.src))
+		if v != d.expv {
+			t.Errorf("test %d: expected v=%d, got v=%d", i, d.expv, v)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGetHatCode(t *testing.T) {
+	data := []struct {
+		src     string
+		expr    rune
+		expn    int
+		expMore bool
+	}{
+		{"^", 0, 0, true},
+		{"^^e", 0, 0, true},
+		{"^^e9x", 'é', 4, false},
+		{"^^E9", 0, 0, false},
+		{"^^^^00e9x", 'é', 8, false},
+		{"^^^^00e", 0, 0, true},
+		{"^^^^^^01d49c", '𝒜', 12, false},
+		{"^^^^^^ffffff", 0, 0, false},
+		{"^2", 0, 0, false},
+		{"^{2}", 0, 0, false},
+	}
+
+	for i, d := range data {
+		r, n, more := getHatCode([]byte(d.src))
+		if r != d.expr {
+			t.Errorf("test %d: expected r=%q, got r=%q", i, d.expr, r)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGetCodeLetter(t *testing.T) {
+	data := []struct {
+		src     string
+		expl    rune
+		expn    int
+		expMore bool
+	}{
+		{"", 0, 0, true},
+		{"{", 0, 0, true},
+		{"x", 0, 0, false},
+		{"{x}", 0, 0, false},
+		{"{\\char\"0438}x", 'и', 12, false},
+		{"{\\char\"0438", 0, 0, true},
+		{"{\\symbol{1080}}", 'и', 15, false},
+		{"{^^^^0438}", 'и', 10, false},
+		{"{^^e9 }", 0, 0, false},
+		{"{\\char\"5C}", 0, 0, false},
+		{"{\\ss}", 0, 0, false},
+	}
+
+	for i, d := range data {
+		l, n, more := getCodeLetter([]byte(d.src))
+		if l != d.expl {
+			t.Errorf("test %d: expected l=%q, got l=%q", i, d.expl, l)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package transformers
+
+// latexDashes is the LaTeX to Unicode mapping of the dashes and ellipsis macros
+var latexDashes = map[string]latexSpecial{
+	"ldots":        {latexSpecialDash, '…'},
+	"dots":         {latexSpecialDash, '…'},
+	"textellipsis": {latexSpecialDash, '…'},
+	"textendash":   {latexSpecialDash, '–'},
+	"textemdash":   {latexSpecialDash, '—'},
+}
+
+// latexControlSymbols is the LaTeX to Unicode mapping of the spacing and hyphenation control symbols
+var latexControlSymbols = map[string]latexSpecial{
+	",": {latexSpecialControl, 0x202F}, // thin space : narrow no-break space
+	"-": {latexSpecialControl, 0xAD},   // discretionary hyphen : soft hyphen
+}
+
+// getDash looks for a TeX dash ligature at the beginning of src, like -- or ---.
+// It returns the dash and the number of bytes read.
+// If there is no ligature, it returns 0 and 0.
+// It returns needMore if src is too short to decide,
+// in this case it returns the dash for the bytes read (if any).
+func getDash(src []byte) (d rune, n int, needMore bool) {
+	for n < len(src) && n < 3 && src[n] == '-' {
+		n++
+	}
+	needMore = n == len(src) && n < 3
+	switch n {
+	case 2:
+		return '–', 2, needMore
+	case 3:
+		return '—', 3, needMore
+	}
+	return 0, 0, needMore
+}
+
+// isOptionValue returns true if a dash ligature between the bytes prev and next
+// is (part of) an option value, like [--] or [label=--]
+func isOptionValue(prev, next byte) bool {
+	return (prev == '[' || prev == ',' || prev == '=') && (next == ']' || next == ',' || next == '=')
+}
+
+// unicodeDashesToLaTeX is the Unicode to LaTeX mapping of the dashes, ellipsis and special spaces
+var unicodeDashesToLaTeX = map[rune]string{
+	'–':    "--",
+	'—':    "---",
+	'…':    "\\ldots",
+	0xA0:   "~",
+	0x202F: "\\,",
+	0x2009: "\\,",
+	0xAD:   "\\-",
+}
+
+// isMathDash returns true if the character r of unicodeDashesToLaTeX is also converted in math mode
+func isMathDash(r rune) bool {
+	return r == '…' || r == 0x202F || r == 0x2009
+}
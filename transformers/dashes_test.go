@@ -0,0 +1,54 @@
+package transformers
+
+import "testing"
+
+func TestGetDash(t *testing.T) {
+	data := []struct {
+		src     string
+		expd    rune
+		expn    int
+		expMore bool
+	}{
+		{"", 0, 0, true},
+		{"-", 0, 0, true},
+		{"-x", 0, 0, false},
+		{"--", '–', 2, true},
+		{"--x", '–', 2, false},
+		{"---", '—', 3, false},
+		{"----", '—', 3, false},
+		{"x", 0, 0, false},
+	}
+
+	for i, d := range data {
+		r, n, more := getDash([]byte(d.src))
+		if r != d.expd {
+			t.Errorf("test %d: expected d=%q, got d=%q", i, d.expd, r)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestIsOptionValue(t *testing.T) {
+	data := []struct {
+		prev, next byte
+		exp        bool
+	}{
+		{'[', ']', true},
+		{'=', ']', true},
+		{',', ',', true},
+		{'a', 'b', false},
+		{' ', ' ', false},
+		{'[', 'a', false},
+	}
+
+	for i, d := range data {
+		if got := isOptionValue(d.prev, d.next); got != d.exp {
+			t.Errorf("test %d: expected %v, got %v", i, d.exp, got)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package transformers
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// definitionCommands are the commands that define a macro, whose body is not text
+var definitionCommands = []string{
+	"def", "edef", "gdef", "xdef",
+	"newcommand", "renewcommand", "providecommand",
+	"chardef",
+}
+
+// maxDefinitionHead is the maximal length of the head of a definition that we look for, like \newcommand{\x}[1][default]{
+const maxDefinitionHead = 256
+
+// definitionAware is a transformer that should know if it transforms the body of a macro definition
+type definitionAware interface {
+	setDefinition(d bool)
+}
+
+// skipTeXSpaces returns the position of the first byte of src, from i, that is not a space
+func skipTeXSpaces(src []byte, i int) int {
+	for i < len(src) && isTeXSpace(src[i]) {
+		i++
+	}
+	return i
+}
+
+// getControlSequence returns the length of the control sequence at the beginning of src,
+// like \x, \@x, \% or the active ~, and needMore if src is too short to know.
+func getControlSequence(src []byte) (n int, needMore bool) {
+	switch {
+	case len(src) == 0:
+		return 0, true
+	case src[0] == '~':
+		return 1, false
+	case src[0] != '\\':
+		return 0, false
+	case len(src) == 1:
+		return 0, true
+	case !isMacroLetter(src[1]):
+		return 2, false
+	}
+	n = 2
+	for n < len(src) && isMacroLetter(src[n]) {
+		n++
+	}
+	return n, n == len(src)
+}
+
+// getCharNumber returns the length of the character number at the beginning of src,
+// like "E9, '351, 233, `é, `\é or `^^e9, and needMore if src is too short to know
+// (with the length read so far, as we can be at the end of the file).
+func getCharNumber(src []byte) (n int, needMore bool) {
+	if len(src) == 0 {
+		return 0, true
+	}
+	if src[0] != '`' {
+		_, n, needMore = getTeXNumber(src)
+		return n, needMore
+	}
+	n = 1
+	if n < len(src) && src[n] == '\\' {
+		n++
+	}
+	_, m, needMore := getHatCode(src[n:])
+	if m > 0 || needMore {
+		return n + m, needMore
+	}
+	if n == len(src) || !utf8.FullRune(src[n:]) {
+		return n, true
+	}
+	_, size := utf8.DecodeRune(src[n:])
+	return n + size, false
+}
+
+// definitionStart checks if src (starting with `\`) is the head of a macro definition,
+// like \def\x#1{, \newcommand{\x}[1]{ or \chardef\x="E9.
+// If it is the case it returns the length n > 0 of the head, up to the opening brace of the body,
+// and body is false if there is no body, like for \chardef\x="E9 whose head ends with the number.
+// It returns needMore if src is too short to decide.
+func definitionStart(src []byte) (n int, body, needMore bool) {
+	// the head is cut: we need more if it can still be short enough
+	short := len(src) < maxDefinitionHead
+	i := 1
+	for i < len(src) && isMacroLetter(src[i]) {
+		i++
+	}
+	if i == len(src) {
+		return 0, false, short
+	}
+	cmd := string(src[1:i])
+	if !slices.Contains(definitionCommands, cmd) {
+		return 0, false, false
+	}
+	command := strings.HasSuffix(cmd, "command")
+	if command && src[i] == '*' {
+		i++
+	}
+	i = skipTeXSpaces(src, i)
+	// the name of the macro, that can be braced with \newcommand, like {\x}
+	braced := command && i < len(src) && src[i] == '{'
+	if braced {
+		i = skipTeXSpaces(src, i+1)
+	}
+	m, more := getControlSequence(src[i:])
+	if more {
+		return 0, false, short
+	}
+	if m == 0 {
+		return 0, false, false
+	}
+	i += m
+	if braced {
+		i = skipTeXSpaces(src, i)
+		if i == len(src) {
+			return 0, false, short
+		}
+		if src[i] != '}' {
+			return 0, false, false
+		}
+		i++
+	}
+	switch {
+	case cmd == "chardef":
+		// the number of the character, like \chardef\x="E9 or \chardef\x=`\é
+		i = skipTeXSpaces(src, i)
+		if i < len(src) && src[i] == '=' {
+			i = skipTeXSpaces(src, i+1)
+		}
+		if i == len(src) {
+			return 0, false, short
+		}
+		m, more := getCharNumber(src[i:])
+		if m == 0 {
+			return 0, false, more && short
+		}
+		return i + m, false, more
+	case command:
+		// the optional number of arguments and default value, like [1][default]
+		for {
+			i = skipTeXSpaces(src, i)
+			if i == len(src) {
+				return 0, false, short
+			}
+			if src[i] != '[' {
+				break
+			}
+			j := bytes.IndexByte(src[i:], ']')
+			if j < 0 {
+				return 0, false, short
+			}
+			i += j + 1
+		}
+		if src[i] != '{' {
+			return 0, false, false
+		}
+		return i + 1, true, false
+	}
+	// the parameter text of \def, like #1#2, is up to the opening brace of the body
+	j := bytes.IndexAny(src[i:], "{}%")
+	if j < 0 {
+		return 0, false, short
+	}
+	if src[i+j] != '{' {
+		return 0, false, false
+	}
+	return i + j + 1, true, false
+}
@@ -0,0 +1,84 @@
+package transformers
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+)
+
+func TestDefinitionStart(t *testing.T) {
+	data := []struct {
+		src     string
+		expn    int
+		expBody bool
+		expMore bool
+	}{
+		{"\\de", 0, false, true},
+		{"\\def", 0, false, true},
+		{"\\def\\x{é}", 7, true, false},
+		{"\\def\\x#1#2{#1}", 11, true, false},
+		{"\\def \\x {", 9, true, false},
+		{"\\def\\x", 0, false, true},
+		{"\\def\\x#1", 0, false, true},
+		{"\\def\\x}", 0, false, false},
+		{"\\definecolor{red}", 0, false, false},
+		{"\\gdef~{", 7, true, false},
+		{"\\newcommand{\\x}{é}", 16, true, false},
+		{"\\newcommand*{ \\x }[1][\\'e]{", 27, true, false},
+		{"\\renewcommand\\x [1] {", 21, true, false},
+		{"\\providecommand\\x[1", 0, false, true},
+		{"\\newcommand\\x x", 0, false, false},
+		{"\\newcommand{x}{", 0, false, false},
+		{"\\chardef\\x=\"E9 ", 15, false, false},
+		{"\\chardef\\x 233\\x", 14, false, false},
+		{"\\chardef\\x=`^^e9", 16, false, false},
+		{"\\chardef\\x=`\\é", 15, false, false},
+		{"\\chardef\\x=\"E9", 14, false, true},
+		{"\\chardef\\x=", 0, false, true},
+		{"\\chardef\\x=\\y", 0, false, false},
+	}
+
+	for i, d := range data {
+		n, body, more := definitionStart([]byte(d.src))
+		if n != d.expn || body != d.expBody || more != d.expMore {
+			t.Errorf("test %d: definitionStart(%q) = %d, %v, %v, want %d, %v, %v", i, d.src, n, body, more, d.expn, d.expBody, d.expMore)
+		}
+	}
+}
+
+func TestToUnicodeAccents_Definitions(t *testing.T) {
+	data := []struct {
+		codes CharCodes // the character codes policy
+		src   string    // source string
+		exp   string    // expected string
+	}{
+		{TextCharCodes, "\\def\\x{\\char\"E9}\\char\"E9", "\\def\\x{\\char\"E9}é"},
+		{TextCharCodes, "\\edef\\x#1{{^^e9}#1\\'e}^^e9", "\\edef\\x#1{{^^e9}#1e\u0301}é"},
+		{TextCharCodes, "\\newcommand{\\x}[1][\\char\"E9]{\\symbol{\"E9}}", "\\newcommand{\\x}[1][\\char\"E9]{\\symbol{\"E9}}"},
+		{TextCharCodes, "\\renewcommand*\\x{$^^e9$ \\text{^^e9}}", "\\renewcommand*\\x{$^^e9$ \\text{^^e9}}"},
+		{TextCharCodes, "\\chardef\\x=\"E9 \\chardef\\y=`^^e9 ^^e9", "\\chardef\\x=\"E9 \\chardef\\y=`^^e9 é"},
+		{TextCharCodes, "\\definecolor{c}{^^e9}", "\\definecolor{c}{é}"},
+		{AllCharCodes, "\\def\\x{\\char\"E9}", "\\def\\x{é}"},
+	}
+
+	for i, d := range data {
+		got, _, err := transform.String(ToUnicodeAccents(WithCharCodes(d.codes)), d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), ToUnicodeAccents(WithCharCodes(d.codes))))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package transformers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fallback is the way the characters without LaTeX form are written when converting to LaTeX
+type Fallback string
+
+const (
+	KeepFallback    Fallback = "keep"    // the characters are kept as UTF-8, like ŋ
+	SymbolFallback  Fallback = "symbol"  // the characters are written by their code, like \symbol{"014B}
+	CharFallback    Fallback = "char"    // the characters are written by their code, like {\char"014B}
+	HatFallback     Fallback = "hat"     // the characters are written with the ^^ notation of XeTeX and LuaTeX, like ^^^^014b
+	UnicharFallback Fallback = "unichar" // the characters are written with the \unichar macro, like \unichar{331}
+	ErrorFallback   Fallback = "error"   // the conversion fails with an UnmappedError
+)
+
+// UnmappedChar is a character without LaTeX form, with the line and the column where it is found (from 1).
+// The column counts the characters in NFC: a combining mark has the column of its base if they compose,
+// like in ơ, and its own column otherwise, like in x̛.
+// With the French quotes, it counts the narrow no-break spaces put inside the guillemets.
+type UnmappedChar struct {
+	Line   int
+	Column int
+	Char   rune
+}
+
+// UnmappedError is the error returned with ErrorFallback if some characters have no LaTeX form
+type UnmappedError struct {
+	Chars []UnmappedChar
+}
+
+// Error returns the list of the characters without LaTeX form and their positions
+func (e *UnmappedError) Error() string {
+	var b strings.Builder
+	b.WriteString("no LaTeX form for the characters")
+	for i, c := range e.Chars {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, " %q (U+%04X) on line %d, column %d", c.Char, c.Char, c.Line, c.Column)
+	}
+	return b.String()
+}
+
+// fallbackForm returns the LaTeX form of the character r that has no LaTeX macro.
+// With ErrorFallback r is reported by the next call of unmappedErr.
+func (t *toLaTeXAccents) fallbackForm(r rune) string {
+	switch t.fallback {
+	case SymbolFallback:
+		return fmt.Sprintf("\\symbol{\"%04X}", r)
+	case CharFallback:
+		// the group ends the hexadecimal number
+		return fmt.Sprintf("{\\char\"%04X}", r)
+	case HatFallback:
+		if r > 0xFFFF {
+			return fmt.Sprintf("^^^^^^%06x", r)
+		}
+		return fmt.Sprintf("^^^^%04x", r)
+	case UnicharFallback:
+		return fmt.Sprintf("\\unichar{%d}", r)
+	case ErrorFallback:
+		t.unmapped = append(t.unmapped, r)
+	}
+	return string(r)
+}
+
+// unmappedErr returns an UnmappedError (without positions) with the characters reported by fallbackForm, if any
+func (t *toLaTeXAccents) unmappedErr() error {
+	if len(t.unmapped) == 0 {
+		return nil
+	}
+	e := &UnmappedError{}
+	for _, r := range t.unmapped {
+		e.Chars = append(e.Chars, UnmappedChar{Char: r})
+	}
+	t.unmapped = t.unmapped[:0]
+	return e
+}
+
+// maxRecent is the number of the last non ASCII characters read whose positions are kept
+const maxRecent = 32
+
+// unmappedChars is a transformer that collects the characters without LaTeX form reported by inner,
+// adding their positions, and returns them all in an UnmappedError at the end.
+// The output of inner is held up to the end, so nothing is written if some characters have no LaTeX form.
+type unmappedChars struct {
+	inner   transform.Transformer
+	lines   int            // the number of new lines already read
+	column  int            // the number of characters already read on the current line
+	cluster []rune         // the last character read, with its combining marks
+	recent  []UnmappedChar // the positions of the last non ASCII characters read
+	chars   []UnmappedChar
+	buf     []byte // the destination of inner
+	out     []byte // the output of inner, held up to the end
+	sent    int    // the number of bytes of out already written
+	done    bool   // inner has processed all the input
+}
+
+// Reset resets the transformer
+func (t *unmappedChars) Reset() {
+	t.lines = 0
+	t.column = 0
+	t.cluster = t.cluster[:0]
+	t.recent = nil
+	t.chars = nil
+	t.out = t.out[:0]
+	t.sent = 0
+	t.done = false
+	t.inner.Reset()
+}
+
+// composes returns true if the combining mark r composes with the last character read, like the horn of ơ,
+// so it has no column of its own in NFC
+func (t *unmappedChars) composes(r rune) bool {
+	before := utf8.RuneCountInString(norm.NFC.String(string(t.cluster)))
+	after := utf8.RuneCountInString(norm.NFC.String(string(t.cluster) + string(r)))
+	return after == before
+}
+
+// read updates the position with the characters of s, and remembers the positions of the non ASCII ones
+func (t *unmappedChars) read(s []byte) {
+	for len(s) > 0 {
+		r, size := utf8.DecodeRune(s)
+		s = s[size:]
+		switch {
+		case r == '\n':
+			t.lines++
+			t.column = 0
+			t.cluster = t.cluster[:0]
+			continue
+		case !unicode.Is(unicode.M, r):
+			t.column++
+			t.cluster = append(t.cluster[:0], r)
+		case t.column == 0 || !t.composes(r):
+			// a stray combining mark has its own column
+			t.column++
+			t.cluster = append(t.cluster, r)
+		default:
+			// the combining mark is in the character of its base, like in ơ
+			t.cluster = append(t.cluster, r)
+		}
+		if r >= utf8.RuneSelf {
+			t.recent = append(t.recent, UnmappedChar{Line: t.lines + 1, Column: t.column, Char: r})
+		}
+	}
+	if len(t.recent) > maxRecent {
+		t.recent = slices.Delete(t.recent, 0, len(t.recent)-maxRecent)
+	}
+}
+
+// locate returns the position of the character reported by inner: the last occurrence of r read before recent[end]
+// (it is reported as soon as it is written), and end is updated to this occurrence.
+func (t *unmappedChars) locate(r rune, end *int) UnmappedChar {
+	for i := *end - 1; i >= 0; i-- {
+		if t.recent[i].Char == r {
+			*end = i
+			return t.recent[i]
+		}
+	}
+	return UnmappedChar{Line: t.lines + 1, Column: t.column, Char: r}
+}
+
+// Transform applies inner and collects the characters without LaTeX form.
+// The output is written at the end, only if all the characters have a LaTeX form.
+func (t *unmappedChars) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for !t.done {
+		if len(t.buf) == 0 {
+			t.buf = make([]byte, 4096)
+		}
+		m, k, err := t.inner.Transform(t.buf, src[nSrc:], atEOF)
+		// inner reports the characters as soon as they are written, so they are already read
+		t.read(src[nSrc : nSrc+k])
+		t.out = append(t.out, t.buf[:m]...)
+		nSrc += k
+		var u *UnmappedError
+		switch {
+		case errors.As(err, &u):
+			// the characters are reported in the order of the text, so they are located from the last one
+			chars := make([]UnmappedChar, len(u.Chars))
+			end := len(t.recent)
+			for i := len(u.Chars) - 1; i >= 0; i-- {
+				chars[i] = t.locate(u.Chars[i].Char, &end)
+			}
+			t.chars = append(t.chars, chars...)
+		case err == transform.ErrShortDst:
+			if m == 0 && k == 0 {
+				// inner writes more than buf at once
+				t.buf = make([]byte, 2*len(t.buf))
+			}
+		case err != nil:
+			return 0, nSrc, err
+		case !atEOF:
+			// the output is held up to the end
+			return 0, nSrc, nil
+		default:
+			t.done = true
+		}
+	}
+	if len(t.chars) > 0 {
+		return 0, nSrc, &UnmappedError{Chars: t.chars}
+	}
+	nDst = copy(dst, t.out[t.sent:])
+	t.sent += nDst
+	if t.sent < len(t.out) {
+		return nDst, nSrc, transform.ErrShortDst
+	}
+	return nDst, nSrc, nil
+}
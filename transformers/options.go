@@ -0,0 +1,273 @@
+package transformers
+
+// options contains the parameters of the transformers.
+type options struct {
+	verbatimEnvs []string   // the environments whose content is passed through untouched
+	convertMath  bool       // convert the content of the math regions
+	symbols      bool       // convert the greek letters and the math symbols
+	ensureMath   bool       // use \ensuremath{...} instead of $...$ for the symbols in text mode
+	alphabets    bool       // convert the math alphabets, like \mathbb{R}
+	scripts      bool       // convert the super/subscripts, like ^{2}
+	quotes       Quotes     // the locale convention of the quotes (not converted if empty)
+	dashes       bool       // convert the dashes, the ellipsis and the special spaces, like -- or ~
+	escape       bool       // the input is plain text whose special characters, like & or %, are escaped
+	plain        bool       // the output is plain text, the special characters are unescaped and the grouping braces removed
+	braces       Braces     // the policy for the braces groups when converting to Unicode
+	protectCase  bool       // keep the case protecting braces, like {NASA}
+	accentArgs   AccentArgs // the style of the text accents arguments, like \'e or \'{e}
+	charGroups   bool       // the accented characters are written in groups, like {\'e}
+	terminator   Terminator // the style of the end of the letter macros, like {\ss} or \ss{}
+	bibtex       bool       // all the accented characters are in BibTeX special characters groups, like {\"{o}}
+	bibFields    []string   // the input is a .bib file and only these fields are converted (if not nil)
+	keepDotless  bool       // keep the dotless i and j under the accents, like \'\i to ı́
+	fallback     Fallback   // the way the characters without LaTeX form are written
+	charCodes    CharCodes  // where the characters given by their code, like \char"E9, are decoded
+	shorthands   []string   // the babel languages whose shorthands, like "a, are converted
+}
+
+// Option is a functional option for the transformers.
+type Option func(*options)
+
+// defaultVerbatimEnvs are the environments whose content is never converted.
+var defaultVerbatimEnvs = []string{
+	"verbatim",
+	"verbatim*",
+	"lstlisting",
+	"minted",
+	"comment",
+}
+
+// WithVerbatimEnvs adds environments to the list of verbatim-like environments.
+// The content of these environments is passed through untouched.
+// By default the list contains verbatim, verbatim*, lstlisting, minted and comment.
+func WithVerbatimEnvs(envs ...string) Option {
+	return func(o *options) {
+		o.verbatimEnvs = append(o.verbatimEnvs, envs...)
+	}
+}
+
+// WithMath sets if the content of the math regions is converted.
+// By default the math ($...$, \(...\), \[...\], equation, align...) is left untouched.
+func WithMath(convert bool) Option {
+	return func(o *options) {
+		o.convertMath = convert
+	}
+}
+
+// WithSymbols sets if the greek letters and the math symbols (\alpha, \leq, \infty...) are converted.
+// By default they are not.
+func WithSymbols(convert bool) Option {
+	return func(o *options) {
+		o.symbols = convert
+	}
+}
+
+// WithEnsureMath sets if the symbols in text mode are written as \ensuremath{\alpha}.
+// By default they are written as $\alpha$.
+func WithEnsureMath(use bool) Option {
+	return func(o *options) {
+		o.ensureMath = use
+	}
+}
+
+// WithMathAlphabets sets if the math alphabets (\mathbb{R}, \mathcal{L}, \mathfrak{g}...) are converted
+// to the Mathematical Alphanumeric Symbols (ℝ, ℒ, 𝔤...).
+// By default they are not.
+func WithMathAlphabets(convert bool) Option {
+	return func(o *options) {
+		o.alphabets = convert
+	}
+}
+
+// WithScripts sets if the super/subscripts (x^{2}, a_{1}, \textsuperscript{2}...) are converted
+// to their Unicode forms (x², a₁, ²...) when all their characters have one.
+// By default they are not.
+func WithScripts(convert bool) Option {
+	return func(o *options) {
+		o.scripts = convert
+	}
+}
+
+// WithQuotes sets the locale convention used to convert the quotes (“…”, \glqq…\grqq, <<…>>...).
+// By default (NoQuotes) the quotes are not converted.
+func WithQuotes(q Quotes) Option {
+	return func(o *options) {
+		o.quotes = q
+	}
+}
+
+// WithDashes sets if the dashes (--, ---), the ellipsis (\ldots) and the special spaces (~, \,, \-)
+// are converted to Unicode (–, —, …, no-break space, narrow no-break space, soft hyphen).
+// The dashes and ~ are not converted in math, in the comments and in the option values like [--].
+// By default they are not.
+func WithDashes(convert bool) Option {
+	return func(o *options) {
+		o.dashes = convert
+	}
+}
+
+// WithEscapeSpecials sets if the input of ToLaTeXAccents is plain text
+// whose LaTeX special characters (& % $ # _ { } ~ ^ \) are escaped, like \& or \textbackslash{}.
+// The input has no math, comments or verbatim in this case.
+// By default the input is supposed to be LaTeX.
+func WithEscapeSpecials(escape bool) Option {
+	return func(o *options) {
+		o.escape = escape
+	}
+}
+
+// WithPlain sets if the output of ToUnicodeAccents is plain text:
+// the escaped special characters (\&, \%, \$, \_, \#, \{, \}, \textbackslash{}...) are unescaped
+// and the braces that only group, like in {Foo}, are removed (but not the macros arguments, like \emph{Foo}).
+// By default the output is LaTeX.
+func WithPlain(plain bool) Option {
+	return func(o *options) {
+		o.plain = plain
+	}
+}
+
+// WithBraces sets the policy for the braces groups when converting to Unicode:
+// KeepBraces, StripAccentGroups ({\'e} to é) or StripRedundantBraces ({Foo} to Foo).
+// The braces of the macros arguments, like \emph{...}, and the braces in math are always kept.
+// By default (StripAccentGroups) only the braces around the converted letters are removed.
+func WithBraces(b Braces) Option {
+	return func(o *options) {
+		o.braces = b
+	}
+}
+
+// WithProtectCase sets if the groups with uppercase letters, like {NASA} or {\'E},
+// keep their braces, as they protect the case in BibTeX.
+// By default they do not, except with WithBibFields.
+func WithProtectCase(protect bool) Option {
+	return func(o *options) {
+		o.protectCase = protect
+	}
+}
+
+// WithAccentArgs sets the style of the arguments of the text accents when converting to LaTeX:
+// AutoAccentArgs (\'e and \c{c}), BracedAccentArgs (\'{e} and \c{c}) or UnbracedAccentArgs (\'e and \c c).
+// By default (AutoAccentArgs) only the letter accents are braced.
+func WithAccentArgs(a AccentArgs) Option {
+	return func(o *options) {
+		o.accentArgs = a
+	}
+}
+
+// WithCharGroups sets if the accented characters are written in groups, like {\'e}, when converting to LaTeX.
+// By default they are not.
+func WithCharGroups(group bool) Option {
+	return func(o *options) {
+		o.charGroups = group
+	}
+}
+
+// WithTerminator sets the style of the end of the letter macros (\ss, \o, \S...) when converting to LaTeX:
+// GroupTerminator ({\ss}), EmptyTerminator (\ss{}) or SpaceTerminator (\ss followed by a space if needed).
+// By default (GroupTerminator) the macros are written in groups.
+func WithTerminator(term Terminator) Option {
+	return func(o *options) {
+		o.terminator = term
+	}
+}
+
+// WithBibTeX sets the BibTeX output style when converting to LaTeX:
+// every accented character (or letter macro) is written in a special character group,
+// like {\"{o}}, {\ss} or {\'{\^{e}}}, that BibTeX sorts and changes case correctly.
+// The diacritics without LaTeX accent are kept in the group of the other accents of their letter, like {\c{c̤}},
+// or else written with the fallback (see WithFallback), without group, like ơ or o\symbol{"031B}.
+// It overrides WithAccentArgs, WithCharGroups and WithTerminator.
+// By default the BibTeX style is not used.
+func WithBibTeX(bibtex bool) Option {
+	return func(o *options) {
+		o.bibtex = bibtex
+		if bibtex {
+			o.accentArgs = BracedAccentArgs
+			o.charGroups = true
+			o.terminator = GroupTerminator
+		}
+	}
+}
+
+// WithBibFields sets that the input is a .bib file and that only the values of the given fields,
+// and of the @string abbreviations, are converted.
+// The keys, the other fields (url, doi, file...), the @preamble and @comment entries
+// and the text between the entries are passed through untouched.
+// Without fields, the title, author, editor, journal, booktitle, publisher, address and note fields are converted.
+// When converting to LaTeX, the accented characters are written in groups, like {\"o},
+// as a `"` ends the quoted values.
+// By default the input is not a .bib file.
+func WithBibFields(fields ...string) Option {
+	return func(o *options) {
+		if len(fields) == 0 {
+			fields = defaultBibFields
+		}
+		o.bibFields = append([]string{}, fields...)
+	}
+}
+
+// WithKeepDotless sets if the dotless i and j under an accent above, like \'\i or \v{\j},
+// are kept as ı and ȷ when converting to Unicode.
+// By default they are converted to the normal letters, like í or ǰ, that are better for search and spell-check.
+func WithKeepDotless(keep bool) Option {
+	return func(o *options) {
+		o.keepDotless = keep
+	}
+}
+
+// WithFallback sets the way the characters without LaTeX form (and the diacritics without LaTeX accent)
+// are written in text mode when converting to LaTeX:
+// KeepFallback (ŋ), SymbolFallback (\symbol{"014B}), CharFallback ({\char"014B}),
+// HatFallback (^^^^014b), UnicharFallback (\unichar{331}),
+// or ErrorFallback that fails with an UnmappedError listing them with their lines and columns
+// (the output is then held up to the end of the input, and nothing is written if it fails).
+// By default (KeepFallback) they are kept as UTF-8.
+func WithFallback(f Fallback) Option {
+	return func(o *options) {
+		o.fallback = f
+	}
+}
+
+// WithCharCodes sets where the characters given by their code are decoded when converting to Unicode:
+// \char"E9, \char'351, \char233, \symbol{"E9}, \unichar{233}, ^^e9, ^^^^00e9 and \accent"7F e
+// (with the accent slots of the T1 and OT1 fonts).
+// AllCharCodes decodes them in the text and in the (converted) math, TextCharCodes only in the text,
+// leaving the math and the macro definitions (\def, \newcommand, \chardef...) untouched, and NoCharCodes nowhere.
+// Only the letters, the digits and the non-ASCII characters are decoded.
+// By default (AllCharCodes) they are decoded everywhere.
+func WithCharCodes(c CharCodes) Option {
+	return func(o *options) {
+		o.charCodes = c
+	}
+}
+
+// WithShorthands sets that the babel shorthands of the given languages, like "a or "s in German, are converted
+// to Unicode (ä, ß...) where the language is selected: after \usepackage[ngerman]{babel} or \selectlanguage{ngerman}
+// (up to the next \selectlanguage), and inside \begin{otherlanguage}{ngerman}...\end{otherlanguage}.
+// Without languages, the shorthands of german, ngerman, austrian, naustrian, swissgerman, nswissgerman,
+// dutch and swedish are converted.
+// By default the shorthands are not converted.
+func WithShorthands(langs ...string) Option {
+	return func(o *options) {
+		if len(langs) == 0 {
+			langs = defaultShorthandLangs
+		}
+		o.shorthands = append([]string{}, langs...)
+	}
+}
+
+// newOptions returns a new options with the options set.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		verbatimEnvs: append([]string{}, defaultVerbatimEnvs...),
+		braces:       StripAccentGroups,
+		accentArgs:   AutoAccentArgs,
+		terminator:   GroupTerminator,
+		fallback:     KeepFallback,
+		charCodes:    AllCharCodes,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
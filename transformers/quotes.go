@@ -0,0 +1,218 @@
+package transformers
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// Quotes is a locale convention for the quotation marks
+type Quotes string
+
+const (
+	NoQuotes      Quotes = ""        // the quotes are not converted
+	EnglishQuotes Quotes = "english" // “…” and ‘…’ written as ``…'' and `…'
+	GermanQuotes  Quotes = "german"  // „…“ and ‚…‘ written as \glqq…\grqq and \glq…\grq
+	FrenchQuotes  Quotes = "french"  // «…» with thin spaces written as \guillemotleft\,…\,\guillemotright
+	PolishQuotes  Quotes = "polish"  // „…” written as ,,…''
+)
+
+// latexQuotes is the LaTeX to Unicode mapping of the quotation marks macros
+var latexQuotes = map[string]latexSpecial{
+	"textquotedblleft":  {latexSpecialQuote, '“'},
+	"textquotedblright": {latexSpecialQuote, '”'},
+	"textquoteleft":     {latexSpecialQuote, '‘'},
+	"textquoteright":    {latexSpecialQuote, '’'},
+	"quotedblbase":      {latexSpecialQuote, '„'},
+	"quotesinglbase":    {latexSpecialQuote, '‚'},
+	"glqq":              {latexSpecialQuote, '„'},
+	"grqq":              {latexSpecialQuote, '“'},
+	"glq":               {latexSpecialQuote, '‚'},
+	"grq":               {latexSpecialQuote, '‘'},
+	"flqq":              {latexSpecialQuote, '«'},
+	"frqq":              {latexSpecialQuote, '»'},
+	"flq":               {latexSpecialQuote, '‹'},
+	"frq":               {latexSpecialQuote, '›'},
+	"guillemotleft":     {latexSpecialQuote, '«'},
+	"guillemotright":    {latexSpecialQuote, '»'},
+	"guilsinglleft":     {latexSpecialQuote, '‹'},
+	"guilsinglright":    {latexSpecialQuote, '›'},
+}
+
+// quoteLigatures are the first bytes of the TeX quotes ligatures, like “ or <<
+const quoteLigatures = "`'<>,"
+
+// getQuote looks for a TeX quote ligature at the beginning of src, like “ or <<.
+// It returns the quotation mark and the number of bytes read.
+// If there is no ligature, it returns 0 and 0.
+// It returns needMore if src is too short to decide,
+// in this case it returns the quotation mark for the first byte (if any).
+func getQuote(src []byte) (q rune, n int, needMore bool) {
+	if len(src) == 0 {
+		return 0, 0, true
+	}
+	var single, double rune
+	switch src[0] {
+	case '`':
+		single, double = '‘', '“'
+	case '\'':
+		single, double = '’', '”'
+	case '<':
+		double = '«'
+	case '>':
+		double = '»'
+	case ',':
+		double = '„'
+	default:
+		return 0, 0, false
+	}
+	if len(src) == 1 {
+		if single == 0 {
+			return 0, 0, true
+		}
+		return single, 1, true
+	}
+	if src[1] == src[0] {
+		return double, 2, false
+	}
+	if single == 0 {
+		return 0, 0, false
+	}
+	return single, 1, false
+}
+
+// unicodeQuotesToLaTeX are the Unicode to LaTeX mappings of the quotation marks for each locale
+var unicodeQuotesToLaTeX = map[Quotes]map[rune]string{
+	EnglishQuotes: {
+		'“': "``",
+		'”': "''",
+		'‘': "`",
+		'’': "'",
+		'„': "\\quotedblbase",
+		'‚': "\\quotesinglbase",
+		'«': "\\guillemotleft",
+		'»': "\\guillemotright",
+		'‹': "\\guilsinglleft",
+		'›': "\\guilsinglright",
+	},
+	GermanQuotes: {
+		'„': "\\glqq",
+		'“': "\\grqq",
+		'‚': "\\glq",
+		'‘': "\\grq",
+		'”': "''",
+		'’': "'",
+		'«': "\\flqq",
+		'»': "\\frqq",
+		'‹': "\\flq",
+		'›': "\\frq",
+	},
+	FrenchQuotes: {
+		'«':    "\\guillemotleft",
+		'»':    "\\guillemotright",
+		'‹':    "\\guilsinglleft",
+		'›':    "\\guilsinglright",
+		'“':    "``",
+		'”':    "''",
+		'‘':    "`",
+		'’':    "'",
+		'„':    "\\quotedblbase",
+		'‚':    "\\quotesinglbase",
+		0x202F: "\\,",
+	},
+	PolishQuotes: {
+		'„': ",,",
+		'”': "''",
+		'“': "``",
+		'‘': "`",
+		'’': "'",
+		'«': "\\guillemotleft",
+		'»': "\\guillemotright",
+	},
+}
+
+// isFrenchSpace returns true if r is a space that can be used around the guillemets
+func isFrenchSpace(r rune) bool {
+	return r == ' ' || r == 0xA0 || r == 0x202F || r == 0x2009
+}
+
+// frenchSpacing is a transformer that puts a narrow no-break space
+// inside the guillemets, replacing the spaces that are already there
+type frenchSpacing struct {
+	open bool // a guillemet was just opened, the spaces are skipped
+	mode texMode
+}
+
+// Reset resets the transformer
+func (t *frenchSpacing) Reset() {
+	t.open = false
+}
+
+// setMode sets the TeX mode of the text to transform
+func (t *frenchSpacing) setMode(m texMode) {
+	t.mode = m
+}
+
+// Transform puts narrow no-break spaces inside the guillemets
+func (t *frenchSpacing) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if t.mode == mathMode {
+		n := copy(dst, src)
+		if n < len(src) {
+			return n, n, transform.ErrShortDst
+		}
+		return n, n, nil
+	}
+	for nSrc < len(src) {
+		r, size := utf8.DecodeRune(src[nSrc:])
+		if r == utf8.RuneError && !utf8.FullRune(src[nSrc:]) && !atEOF {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		if r == utf8.RuneError && size <= 1 {
+			return nDst, nSrc, fmt.Errorf("invalid UTF-8 encoding")
+		}
+		if isFrenchSpace(r) {
+			if t.open {
+				// skip the spaces after the opening guillemet
+				nSrc += size
+				continue
+			}
+			// look for a closing guillemet after the spaces
+			i := nSrc
+			for i < len(src) {
+				s, n := utf8.DecodeRune(src[i:])
+				if !isFrenchSpace(s) {
+					break
+				}
+				i += n
+			}
+			if (i == len(src) || !utf8.FullRune(src[i:])) && !atEOF {
+				// we need more data to know what follows the spaces
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if s, _ := utf8.DecodeRune(src[i:]); s == '»' || s == '›' {
+				// skip the spaces before the closing guillemet
+				nSrc = i
+				continue
+			}
+			if !write(dst, src[nSrc:i], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc = i
+			continue
+		}
+		n := nDst
+		if t.open || r == '»' || r == '›' {
+			if !writeRune(dst, 0x202F, &n) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+		}
+		if !write(dst, src[nSrc:nSrc+size], &n) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		t.open = r == '«' || r == '‹'
+		nDst = n
+		nSrc += size
+	}
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,113 @@
+package transformers
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+)
+
+func TestGetQuote(t *testing.T) {
+	data := []struct {
+		src     string
+		expq    rune
+		expn    int
+		expMore bool
+	}{
+		{"", 0, 0, true},
+		{"`", '‘', 1, true},
+		{"``", '“', 2, false},
+		{"`x", '‘', 1, false},
+		{"'", '’', 1, true},
+		{"''", '”', 2, false},
+		{"'s", '’', 1, false},
+		{"<", 0, 0, true},
+		{"<<", '«', 2, false},
+		{"<x", 0, 0, false},
+		{">>", '»', 2, false},
+		{",,", '„', 2, false},
+		{", ", 0, 0, false},
+		{"x", 0, 0, false},
+	}
+
+	for i, d := range data {
+		q, n, more := getQuote([]byte(d.src))
+		if q != d.expq {
+			t.Errorf("test %d: expected q=%q, got q=%q", i, d.expq, q)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestToUnicodeAccents_GermanQuotes(t *testing.T) {
+	data := []struct {
+		shorthands []string // the languages whose shorthands are converted
+		src        string   // source string
+		exp        string   // expected string
+	}{
+		{nil, "``Hallo'' \\glqq x\\grqq", "“Hallo” „x“"},
+		{nil, "\"`a\"' \"``a\"''", "\"`a\"' \"`‘a\"'’"},
+		{defaultShorthandLangs, "\\selectlanguage{ngerman}\"`a\"' ``a''", "\\selectlanguage{ngerman}„a“ “a”"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{quotes: GermanQuotes, shorthands: d.shorthands}}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		lat = &toUnicodeAccents{options: options{quotes: GermanQuotes, shorthands: d.shorthands}}
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), lat))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+func TestFrenchSpacing(t *testing.T) {
+	data := []struct {
+		src string // source string
+		exp string // expected string
+	}{
+		{"«Salut»", "« Salut »"},
+		{"« Salut »", "« Salut »"},
+		{"« Salut »", "« Salut »"},
+		{"« Salut »", "« Salut »"},
+		{"a  b", "a  b"},
+		{"‹oui›", "‹ oui ›"},
+		{"«", "«"},
+	}
+
+	for i, d := range data {
+		got, _, err := transform.String(&frenchSpacing{}, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		r := transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), &frenchSpacing{})
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
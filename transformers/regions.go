@@ -0,0 +1,411 @@
+package transformers
+
+import (
+	"bytes"
+	"slices"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// regionKind is the kind of a region of the LaTeX source
+type regionKind int
+
+const (
+	regionText       regionKind = iota // normal text, converted by the inner transformer
+	regionComment                      // % comment, converted but without looking for other regions
+	regionVerb                         // \verb|...|, passed through untouched
+	regionVerbatim                     // verbatim-like environment, passed through untouched
+	regionMath                         // math, passed through untouched unless requested
+	regionDefinition                   // body of a macro definition, like \def\x{...}, converted as its parent
+)
+
+// texMode is the TeX mode in which the inner transformer works
+type texMode int
+
+const (
+	textMode texMode = iota
+	mathMode
+)
+
+// modalTransformer is a transformer that can depend on the TeX mode
+type modalTransformer interface {
+	transform.Transformer
+	setMode(m texMode)
+}
+
+// commentAware is a transformer that should know if it transforms a comment
+type commentAware interface {
+	setComment(c bool)
+}
+
+// region is a region of the LaTeX source
+type region struct {
+	kind       regionKind
+	end        string  // the string that closes the region
+	mode       texMode // the mode of the content
+	raw        bool    // the content is passed through untouched
+	definition bool    // the content is in the body of a macro definition
+	depth      int     // the brace depth, for text inside math or definitions (closed by "}")
+}
+
+// maxEnvName is the maximal length of an environment name we look for
+const maxEnvName = 64
+
+// mathEnvs are the environments whose content is in math mode
+var mathEnvs = []string{
+	"equation", "equation*",
+	"align", "align*",
+	"gather", "gather*",
+	"multline", "multline*",
+	"flalign", "flalign*",
+	"alignat", "alignat*",
+	"eqnarray", "eqnarray*",
+	"math", "displaymath",
+}
+
+// textInMath are the macros whose argument is in text mode inside math
+var textInMath = []string{
+	"text", "textrm", "textit", "textbf", "textsf", "texttt", "textnormal", "mbox",
+}
+
+// texRegions is a transformer that tracks the regions of the LaTeX source.
+// It passes the verbatim-like regions (and by default the math) untouched
+// and sends everything else to the inner transformer, setting its mode.
+type texRegions struct {
+	inner       modalTransformer
+	envs        []string // the verbatim-like environments
+	math        bool     // convert the math content
+	definitions bool     // track the bodies of the macro definitions
+	stack       []region // the open regions, the first one is the top level text
+	escaped     bool     // the first byte of the next src is escaped by an already processed `\`
+}
+
+// newTexRegions returns a transformer that applies inner to the regions that should be converted
+func newTexRegions(inner modalTransformer, o *options) transform.Transformer {
+	return &texRegions{
+		inner:       inner,
+		envs:        o.verbatimEnvs,
+		math:        o.convertMath,
+		definitions: o.charCodes == TextCharCodes,
+		stack:       []region{{}},
+	}
+}
+
+// Reset resets the transformer
+func (t *texRegions) Reset() {
+	t.stack = t.stack[:1]
+	t.escaped = false
+	t.inner.Reset()
+}
+
+// top returns the current region
+func (t *texRegions) top() *region {
+	return &t.stack[len(t.stack)-1]
+}
+
+// open pushes the region r, setting its mode and raw flag according to the current region
+func (t *texRegions) open(r region) {
+	parent := t.top()
+	r.definition = parent.definition || r.kind == regionDefinition
+	switch r.kind {
+	case regionVerb, regionVerbatim:
+		r.raw = true
+	case regionMath:
+		r.raw = parent.raw || !t.math
+		r.mode = mathMode
+	case regionComment, regionDefinition:
+		r.raw = parent.raw
+		r.mode = parent.mode
+	}
+	t.stack = append(t.stack, r)
+}
+
+// setMode sets the mode of the inner transformer for the region r
+func (t *texRegions) setMode(r *region) {
+	t.inner.setMode(r.mode)
+	if c, ok := t.inner.(commentAware); ok {
+		c.setComment(r.kind == regionComment)
+	}
+	if d, ok := t.inner.(definitionAware); ok {
+		d.setDefinition(r.definition)
+	}
+}
+
+// close pops the current region
+func (t *texRegions) close() {
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// hasPartialPrefix returns true if src is a strict prefix of s
+func hasPartialPrefix(src []byte, s string) bool {
+	return len(src) < len(s) && string(src) == s[:len(src)]
+}
+
+// scanEscapes returns the brace depth variation of src and
+// true if src ends with a `\` that escapes the byte following src.
+// If escaped is true, the first byte of src is escaped.
+func scanEscapes(src []byte, escaped bool) (delta int, trailing bool) {
+	i := 0
+	if escaped {
+		i = 1
+	}
+	for ; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '{':
+			delta++
+		case '}':
+			delta--
+		}
+	}
+	return delta, i > len(src)
+}
+
+// regionStart checks if src (starting with `\`) opens a region inside the current region.
+// If it is the case it returns the region and the length n > 0 of the opening string.
+// It returns needMore if src is too short to decide.
+func (t *texRegions) regionStart(src []byte) (r region, n int, needMore bool) {
+	if len(src) < 2 {
+		return region{}, 0, true
+	}
+	if t.top().kind == regionMath {
+		// look for a text inside math, like \text{...}
+		i := 1
+		for i < len(src) && isLatin(src[i]) {
+			i++
+		}
+		if i == len(src) {
+			return region{}, 0, true
+		}
+		if src[i] != '{' || !slices.Contains(textInMath, string(src[1:i])) {
+			return region{}, 0, false
+		}
+		return region{kind: regionText, end: "}"}, i + 1, false
+	}
+	switch src[1] {
+	case '(':
+		return region{kind: regionMath, end: "\\)"}, 2, false
+	case '[':
+		return region{kind: regionMath, end: "\\]"}, 2, false
+	}
+	const verb, begin = "\\verb", "\\begin{"
+	if hasPartialPrefix(src, verb) || hasPartialPrefix(src, begin) {
+		return region{}, 0, true
+	}
+	if bytes.HasPrefix(src, []byte(verb)) {
+		n = len(verb)
+		if n < len(src) && src[n] == '*' {
+			n++
+		}
+		if n == len(src) || !utf8.FullRune(src[n:]) {
+			return region{}, 0, true
+		}
+		if isLatin(src[n]) || src[n] == ' ' || src[n] == '\n' {
+			// this is some other macro or an invalid \verb
+			return region{}, 0, false
+		}
+		_, size := utf8.DecodeRune(src[n:])
+		return region{kind: regionVerb, end: string(src[n : n+size])}, n + size, false
+	}
+	if bytes.HasPrefix(src, []byte(begin)) {
+		i := bytes.IndexByte(src[len(begin):], '}')
+		if i < 0 {
+			return region{}, 0, len(src) < len(begin)+maxEnvName
+		}
+		name := string(src[len(begin) : len(begin)+i])
+		n = len(begin) + i + 1
+		if slices.Contains(t.envs, name) {
+			return region{kind: regionVerbatim, end: "\\end{" + name + "}"}, n, false
+		}
+		if slices.Contains(mathEnvs, name) {
+			return region{kind: regionMath, end: "\\end{" + name + "}"}, n, false
+		}
+	}
+	if t.definitions {
+		// the head of the definition is the opening string, and the body is closed by "}"
+		n, body, needMore := definitionStart(src)
+		if !body {
+			return region{kind: regionDefinition}, n, needMore
+		}
+		return region{kind: regionDefinition, end: "}"}, n, needMore
+	}
+	return region{}, 0, false
+}
+
+// nextEvent looks for the next opening or closing of a region in src.
+// It returns the position i of the event and the length n of its opening (or closing) string.
+// If a region is opened, it is returned as r (and n > 0).
+// If the current region is closed, closes is true.
+// If there is no event, i is len(src).
+// If needMore is true, src[i:] can be the beginning of some event.
+func (t *texRegions) nextEvent(src []byte, atEOF bool) (i, n int, r region, closes, needMore bool) {
+	top := t.top()
+	if top.kind == regionComment {
+		// the comment ends at the new line (that is not part of it)
+		if i = bytes.IndexByte(src, '\n'); i < 0 {
+			return len(src), 0, region{}, false, false
+		}
+		return i, 0, region{}, true, false
+	}
+	if t.escaped {
+		i = 1
+	}
+	depth := top.depth
+	for i < len(src) {
+		j := bytes.IndexAny(src[i:], "\\%${}")
+		if j < 0 {
+			break
+		}
+		i += j
+		switch src[i] {
+		case '%':
+			return i, 1, region{kind: regionComment, end: "\n"}, false, false
+		case '{':
+			depth++
+		case '}':
+			if top.end == "}" && depth == 0 {
+				return i, 1, region{}, true, false
+			}
+			depth--
+		case '$':
+			if i+1 == len(src) && !atEOF {
+				return i, 0, region{}, false, true
+			}
+			double := i+1 < len(src) && src[i+1] == '$'
+			switch {
+			case top.kind != regionMath && double:
+				return i, 2, region{kind: regionMath, end: "$$"}, false, false
+			case top.kind != regionMath:
+				return i, 1, region{kind: regionMath, end: "$"}, false, false
+			case top.end == "$$" && double:
+				return i, 2, region{}, true, false
+			case top.end == "$":
+				return i, 1, region{}, true, false
+			}
+		case '\\':
+			if top.kind == regionMath && top.end[0] == '\\' {
+				if bytes.HasPrefix(src[i:], []byte(top.end)) {
+					return i, len(top.end), region{}, true, false
+				}
+				if hasPartialPrefix(src[i:], top.end) && !atEOF {
+					return i, 0, region{}, false, true
+				}
+			}
+			r, n, needMore = t.regionStart(src[i:])
+			if needMore && !atEOF {
+				return i, 0, region{}, false, true
+			}
+			if n > 0 {
+				return i, n, r, false, false
+			}
+			// skip the escaped character
+			i++
+		}
+		i++
+	}
+	return len(src), 0, region{}, false, false
+}
+
+// regionEnd looks for the end of the current verbatim-like region in src.
+// It returns the number of bytes of the content of the region, before the closing string,
+// the length of the closing string and true if it was found.
+// \verb regions are also closed (without closing string) by a new line.
+func (t *texRegions) regionEnd(src []byte, atEOF bool) (n, closing int, found bool) {
+	top := t.top()
+	end := []byte(top.end)
+	i := bytes.Index(src, end)
+	if top.kind == regionVerb {
+		if j := bytes.IndexByte(src, '\n'); j >= 0 && (i < 0 || j < i) {
+			return j, 0, true
+		}
+	}
+	if i >= 0 {
+		return i, len(end), true
+	}
+	if atEOF {
+		return len(src), 0, false
+	}
+	// keep the bytes that can be the beginning of the closing string
+	return max(0, len(src)-len(end)+1), 0, false
+}
+
+// Transform converts the content of the regions that should be converted with the inner transformer
+func (t *texRegions) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		top := t.top()
+		if top.kind == regionVerb || top.kind == regionVerbatim {
+			n, closing, found := t.regionEnd(src[nSrc:], atEOF)
+			// the region can be long, so copy as much as possible
+			k := copy(dst[nDst:], src[nSrc:nSrc+n])
+			nDst += k
+			nSrc += k
+			if k < n {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			if !found {
+				if atEOF {
+					continue
+				}
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			// the closing string is copied at once, to find it again if dst is too short
+			if !write(dst, src[nSrc:nSrc+closing], &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nSrc += closing
+			t.close()
+			continue
+		}
+		// process the content up to the next event
+		i, n, r, closes, needMore := t.nextEvent(src[nSrc:], atEOF)
+		var k int
+		if top.raw {
+			k = copy(dst[nDst:], src[nSrc:nSrc+i])
+			nDst += k
+			if k < i {
+				err = transform.ErrShortDst
+			}
+		} else {
+			var m int
+			t.setMode(top)
+			m, k, err = t.inner.Transform(dst[nDst:], src[nSrc:nSrc+i], atEOF || closes || n > 0)
+			nDst += m
+		}
+		if top.kind != regionComment {
+			var delta int
+			delta, t.escaped = scanEscapes(src[nSrc:nSrc+k], t.escaped)
+			top.depth += delta
+		}
+		nSrc += k
+		if err != nil {
+			return nDst, nSrc, err
+		}
+		if needMore {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		if !closes && n == 0 {
+			continue
+		}
+		// copy the opening (or closing) string of the region
+		if !write(dst, src[nSrc:nSrc+n], &nDst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nSrc += n
+		t.escaped = false
+		if closes {
+			t.close()
+		} else if r.end != "" {
+			// the definitions without body, like \chardef\x="E9, have nothing to close
+			t.open(r)
+		}
+	}
+	if top := t.top(); atEOF && !top.raw {
+		// let the inner transformer flush its pending data
+		t.setMode(top)
+		m, _, err := t.inner.Transform(dst[nDst:], src[nSrc:], true)
+		return nDst + m, nSrc, err
+	}
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,153 @@
+package transformers
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestRegionStart(t *testing.T) {
+	data := []struct {
+		src     string
+		expr    region
+		expn    int
+		expMore bool
+	}{
+		{"\\", region{}, 0, true},
+		{"\\ve", region{}, 0, true},
+		{"\\verb", region{}, 0, true},
+		{"\\verb*", region{}, 0, true},
+		{"\\verb|", region{kind: regionVerb, end: "|"}, 6, false},
+		{"\\verb*|", region{kind: regionVerb, end: "|"}, 7, false},
+		{"\\verb+x+", region{kind: regionVerb, end: "+"}, 6, false},
+		{"\\verbatim", region{}, 0, false},
+		{"\\verb x", region{}, 0, false},
+		{"\\begin", region{}, 0, true},
+		{"\\begin{verb", region{}, 0, true},
+		{"\\begin{verbatim}", region{kind: regionVerbatim, end: "\\end{verbatim}"}, 16, false},
+		{"\\begin{verbatim*}", region{kind: regionVerbatim, end: "\\end{verbatim*}"}, 17, false},
+		{"\\begin{minted}{go}", region{kind: regionVerbatim, end: "\\end{minted}"}, 14, false},
+		{"\\begin{itemize}", region{}, 0, false},
+		{"\\'e", region{}, 0, false},
+		{"\\(", region{kind: regionMath, end: "\\)"}, 2, false},
+		{"\\[", region{kind: regionMath, end: "\\]"}, 2, false},
+		{"\\\\[", region{}, 0, false},
+		{"\\begin{align*}", region{kind: regionMath, end: "\\end{align*}"}, 14, false},
+	}
+
+	tr := newTexRegions(nil, newOptions()).(*texRegions)
+	for i, d := range data {
+		r, n, more := tr.regionStart([]byte(d.src))
+		if r != d.expr {
+			t.Errorf("test %d: expected region=%v, got region=%v", i, d.expr, r)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestTexRegions(t *testing.T) {
+	data := []struct {
+		src  string   // source string
+		opts []Option // transformer options
+		exp  string   // expected result
+	}{
+		{"\\'e", nil, "é"},
+		{"\\'e \\verb|\\'e| \\'e", nil, "é \\verb|\\'e| é"},
+		{"\\verb*+\\'e+\\'e", nil, "\\verb*+\\'e+é"},
+		{"\\verb|\\'e\n\\'e", nil, "\\verb|\\'e\né"},
+		{"\\verbatim\\'e", nil, "\\verbatimé"},
+		{"\\'a\\begin{verbatim}\\'e\\end{verbatim}\\'e", nil, "á\\begin{verbatim}\\'e\\end{verbatim}é"},
+		{"\\begin{lstlisting}\\'e\\end{verbatim}\\'e", nil, "\\begin{lstlisting}\\'e\\end{verbatim}\\'e"},
+		{"\\begin{minted}{tex}\\'e\\end{minted}\\'e", nil, "\\begin{minted}{tex}\\'e\\end{minted}é"},
+		{"\\begin{itemize}\\'e\\end{itemize}", nil, "\\begin{itemize}é\\end{itemize}"},
+		{"\\begin{code}\\'e\\end{code}\\'e", []Option{WithVerbatimEnvs("code")}, "\\begin{code}\\'e\\end{code}é"},
+		{"% \\'e \\begin{verbatim}\n\\'e", nil, "% é \\begin{verbatim}\né"},
+		{"\\% \\begin{comment}\\'e\\end{comment}", nil, "\\% \\begin{comment}\\'e\\end{comment}"},
+		{"\\\\verb|\\'e|", nil, "\\\\verb|é|"},
+		{"\\'e $\\'e$ \\'e", nil, "é $\\'e$ é"},
+		{"\\'e $\\'e$ \\'e", []Option{WithMath(true)}, "é $é$ é"},
+		{"\\$\\'e\\$", nil, "\\$é\\$"},
+		{"$$\\'e$$\\'e", nil, "$$\\'e$$é"},
+		{"\\(\\'e\\)\\[\\'e\\]\\'e", nil, "\\(\\'e\\)\\[\\'e\\]é"},
+		{"\\begin{align*}\\'e\\end{align*}\\'e", nil, "\\begin{align*}\\'e\\end{align*}é"},
+		{"$\\text{\\'e $\\'e$}\\'e$\\'e", nil, "$\\text{é $\\'e$}\\'e$é"},
+		{"$\\text{\\'e $\\'e$ {\\'e}}\\'e$\\'e", []Option{WithMath(true)}, "$\\text{é $é$ é}é$é"},
+		{"$x % $\n\\'e$\\'e", nil, "$x % $\n\\'e$é"},
+		{"\\begin{verbatim}$\\end{verbatim}\\'e", nil, "\\begin{verbatim}$\\end{verbatim}é"},
+		{"$\\mathbb{NZ}\\alpha$", []Option{WithMath(true), WithMathAlphabets(true), WithSymbols(true)}, "$ℕℤα$"},
+		{"$x^{2}+a_{n+1}$ 1\\textsuperscript{er}", []Option{WithMath(true), WithScripts(true)}, "$x²+aₙ₊₁$ 1ᵉʳ"},
+		{"``a'' $f'$ \\verb|``|", []Option{WithQuotes(EnglishQuotes)}, "“a” $f'$ \\verb|``|"},
+		{"<< Salut >> \\guillemotleft\\,oui\\,\\guillemotright", []Option{WithQuotes(FrenchQuotes)}, "«\u202fSalut\u202f» «\u202foui\u202f»"},
+		{"{\\'e} \\& $\\{x\\}$ % {c}\n\\%", []Option{WithPlain(true)}, "é & $\\{x\\}$ % {c}\n%"},
+		{"a--b % c--d\n$a--b$ \\verb|--| [--]", []Option{WithDashes(true)}, "a–b % c--d\n$a--b$ \\verb|--| [--]"},
+	}
+
+	for i, d := range data {
+		tr := transform.Chain(ToUnicodeAccents(d.opts...), norm.NFC)
+		got, _, err := transform.String(tr, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		tr.Reset()
+		r := transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), tr)
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+// transformWithDst applies tr to src with a dst buffer of the given size
+func transformWithDst(tr transform.Transformer, src string, size int) (string, error) {
+	var out []byte
+	dst, in := make([]byte, size), []byte(src)
+	for {
+		nDst, nSrc, err := tr.Transform(dst, in, true)
+		out = append(out, dst[:nDst]...)
+		in = in[nSrc:]
+		if err != transform.ErrShortDst || (nDst == 0 && nSrc == 0) {
+			return string(out), err
+		}
+	}
+}
+
+func TestTexRegions_ShortDst(t *testing.T) {
+	long := "\\begin{lstlisting}\n" + strings.Repeat("x", 100) + "\n\\end{lstlisting}"
+	data := []struct {
+		tr       transform.Transformer
+		src, exp string
+	}{
+		{ToUnicodeAccents(), "\\'a" + long + " \\'e \\verb|\\'e| \\'e", "a\u0301" + long + " e\u0301 \\verb|\\'e| e\u0301"},
+		{ToLaTeXAccents(), "ß" + long + " e\u0301 \\verb|e\u0301| e\u0301", "{\\ss}" + long + " \\'e \\verb|e\u0301| \\'e"},
+	}
+
+	for i, d := range data {
+		// the dst buffer is too short for the region, but not for its opening and closing strings
+		for size := len("\\begin{lstlisting}"); size < 64; size++ {
+			d.tr.Reset()
+			got, err := transformWithDst(d.tr, d.src, size)
+			if err != nil {
+				t.Errorf("test %d (size %d): unexpected error: %v", i, size, err)
+			}
+			if got != d.exp {
+				t.Errorf("test %d (size %d): expected %q, got %q", i, size, d.exp, got)
+			}
+		}
+	}
+}
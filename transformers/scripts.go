@@ -0,0 +1,76 @@
+package transformers
+
+// superscripts are the characters that have a Unicode superscript form
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴',
+	'5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾',
+	'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 'f': 'ᶠ', 'g': 'ᵍ',
+	'h': 'ʰ', 'i': 'ⁱ', 'j': 'ʲ', 'k': 'ᵏ', 'l': 'ˡ', 'm': 'ᵐ', 'n': 'ⁿ',
+	'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ', 't': 'ᵗ', 'u': 'ᵘ', 'v': 'ᵛ',
+	'w': 'ʷ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ',
+	'A': 'ᴬ', 'B': 'ᴮ', 'D': 'ᴰ', 'E': 'ᴱ', 'G': 'ᴳ', 'H': 'ᴴ', 'I': 'ᴵ',
+	'J': 'ᴶ', 'K': 'ᴷ', 'L': 'ᴸ', 'M': 'ᴹ', 'N': 'ᴺ', 'O': 'ᴼ', 'P': 'ᴾ',
+	'R': 'ᴿ', 'T': 'ᵀ', 'U': 'ᵁ', 'V': 'ⱽ', 'W': 'ᵂ',
+}
+
+// subscripts are the characters that have a Unicode subscript form
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄',
+	'5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ',
+	'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ', 'p': 'ₚ', 'r': 'ᵣ', 's': 'ₛ', 't': 'ₜ',
+	'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+}
+
+// latexScripts is the LaTeX to Unicode mapping of the text super/subscripts macros,
+// the script is identified by its math character (^ or _)
+var latexScripts = map[string]latexSpecial{
+	"textsuperscript": {latexSpecialScript, '^'},
+	"textsubscript":   {latexSpecialScript, '_'},
+}
+
+// scriptChar is a super/subscript character
+type scriptChar struct {
+	script rune // ^ or _
+	char   rune // the ASCII character
+}
+
+// unicodeScriptsToLaTeX is the Unicode to LaTeX mapping of the super/subscript characters
+var unicodeScriptsToLaTeX = scriptsByRune()
+
+// scriptsByRune returns the Unicode to LaTeX mapping of the super/subscript characters
+func scriptsByRune() map[rune]scriptChar {
+	m := make(map[rune]scriptChar)
+	for c, r := range superscripts {
+		m[r] = scriptChar{'^', c}
+	}
+	for c, r := range subscripts {
+		m[r] = scriptChar{'_', c}
+	}
+	return m
+}
+
+// scriptConverter returns the function that converts a character to its super/subscript form
+func scriptConverter(script rune) func(rune) (rune, bool) {
+	table := superscripts
+	if script == '_' {
+		table = subscripts
+	}
+	return func(c rune) (rune, bool) {
+		r, ok := table[c]
+		return r, ok
+	}
+}
+
+// scriptGroup returns the LaTeX group opening for the super/subscript in the given mode
+func scriptGroup(script rune, mode texMode) string {
+	switch {
+	case mode == mathMode:
+		return string(script) + "{"
+	case script == '^':
+		return "\\textsuperscript{"
+	}
+	return "\\textsubscript{"
+}
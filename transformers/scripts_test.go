@@ -0,0 +1,35 @@
+package transformers
+
+import "testing"
+
+func TestScriptGroup(t *testing.T) {
+	data := []struct {
+		script rune    // ^ or _
+		mode   texMode // the TeX mode
+		exp    string  // expected group opening
+	}{
+		{'^', mathMode, "^{"},
+		{'_', mathMode, "_{"},
+		{'^', textMode, "\\textsuperscript{"},
+		{'_', textMode, "\\textsubscript{"},
+	}
+
+	for i, d := range data {
+		if got := scriptGroup(d.script, d.mode); got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestUnicodeScriptsToLaTeX(t *testing.T) {
+	for c, r := range superscripts {
+		if s := unicodeScriptsToLaTeX[r]; s.script != '^' || s.char != c {
+			t.Errorf("%q: expected ^%c, got %c%c", r, c, s.script, s.char)
+		}
+	}
+	for c, r := range subscripts {
+		if s := unicodeScriptsToLaTeX[r]; s.script != '_' || s.char != c {
+			t.Errorf("%q: expected _%c, got %c%c", r, c, s.script, s.char)
+		}
+	}
+}
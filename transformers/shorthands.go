@@ -0,0 +1,206 @@
+package transformers
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// hyphenShorthands are the babel shorthands, after the active `"`, shared by the languages
+var hyphenShorthands = map[string]string{
+	"-":  "\u00AD", // hyphenation point, like \- : soft hyphen
+	"\"": "\u200B", // break point without hyphen : zero width space
+	"|":  "\u200C", // ligature break, like in Auf"|lage : zero width non-joiner
+	"~":  "\u2011", // hyphen without break point : non-breaking hyphen
+	"=":  "-",      // hyphen with break point
+}
+
+// withHyphenShorthands returns the shorthands of a language, with the shared hyphenation ones
+func withHyphenShorthands(letters map[string]string) map[string]string {
+	m := maps.Clone(hyphenShorthands)
+	maps.Copy(m, letters)
+	return m
+}
+
+// germanShorthands are the shorthands of the german, ngerman, austrian... languages
+var germanShorthands = withHyphenShorthands(map[string]string{
+	"a": "ä", "e": "ë", "i": "ï", "o": "ö", "u": "ü",
+	"A": "Ä", "E": "Ë", "I": "Ï", "O": "Ö", "U": "Ü",
+	"s": "ß", "z": "ß", "S": "SS", "Z": "SZ",
+	// the old hyphenation of the double consonants, like Zu"cker or Schi"ffahrt
+	"ck": "ck", "ff": "ff", "ll": "ll", "mm": "mm", "nn": "nn", "pp": "pp", "rr": "rr", "tt": "tt",
+	"CK": "CK", "FF": "FF", "LL": "LL", "MM": "MM", "NN": "NN", "PP": "PP", "RR": "RR", "TT": "TT",
+	"`": "„", "'": "“", "<": "«", ">": "»",
+})
+
+// dutchShorthands are the shorthands of the dutch language
+var dutchShorthands = withHyphenShorthands(map[string]string{
+	"a": "ä", "e": "ë", "i": "ï", "o": "ö", "u": "ü",
+	"A": "Ä", "E": "Ë", "I": "Ï", "O": "Ö", "U": "Ü",
+	"ij": "ij", "IJ": "IJ",
+	"`": "„", "'": "”",
+})
+
+// swedishShorthands are the shorthands of the swedish language
+var swedishShorthands = withHyphenShorthands(map[string]string{
+	"a": "ä", "o": "ö", "A": "Ä", "O": "Ö",
+})
+
+// babelShorthands are the shorthands by babel language
+var babelShorthands = map[string]map[string]string{
+	"german":       germanShorthands,
+	"ngerman":      germanShorthands,
+	"austrian":     germanShorthands,
+	"naustrian":    germanShorthands,
+	"swissgerman":  germanShorthands,
+	"nswissgerman": germanShorthands,
+	"dutch":        dutchShorthands,
+	"swedish":      swedishShorthands,
+}
+
+// defaultShorthandLangs are the languages whose shorthands are converted by default
+var defaultShorthandLangs = []string{
+	"german",
+	"ngerman",
+	"austrian",
+	"naustrian",
+	"swissgerman",
+	"nswissgerman",
+	"dutch",
+	"swedish",
+}
+
+// getShorthand returns the conversion of the shorthand at the beginning of src, like "a, and the number of bytes read.
+// src starts with the active `"`.
+// It returns 0 bytes if src does not start with a shorthand of the table, and needMore if src is too short to know
+// (with the shorthand read so far, like "i before "ij, as we can be at the end of the file).
+func getShorthand(table map[string]string, src []byte) (s string, n int, needMore bool) {
+	if len(src) < 2 {
+		return "", 0, true
+	}
+	if len(src) < 3 {
+		// a two letters shorthand, like "ck, can start here
+		for k := range table {
+			if len(k) == 2 && k[0] == src[1] {
+				s, ok := table[string(src[1:2])]
+				if !ok {
+					return "", 0, true
+				}
+				return s, 2, true
+			}
+		}
+	} else if s, ok := table[string(src[1:3])]; ok {
+		return s, 3, false
+	}
+	if s, ok := table[string(src[1:2])]; ok {
+		return s, 2, false
+	}
+	return "", 0, false
+}
+
+// languageSwitch is a babel command that changes the language
+type languageSwitch int
+
+const (
+	noSwitch     languageSwitch = iota
+	selectSwitch                // \selectlanguage{lang}, up to the next one
+	beginSwitch                 // \begin{otherlanguage}{lang}, up to the end of the environment
+	endSwitch                   // \end{otherlanguage}
+	mainSwitch                  // \usepackage[...,lang]{babel}, the main language
+)
+
+// languageCommands are the beginnings of the babel commands that change the language
+var languageCommands = []struct {
+	prefix string
+	sw     languageSwitch
+}{
+	{"\\selectlanguage{", selectSwitch},
+	{"\\begin{otherlanguage}{", beginSwitch},
+	{"\\begin{otherlanguage*}{", beginSwitch},
+	{"\\end{otherlanguage}", endSwitch},
+	{"\\end{otherlanguage*}", endSwitch},
+	{"\\usepackage[", mainSwitch},
+}
+
+// maxLanguageOptions is the maximal length of the language name, or of the options of babel, that we look for
+const maxLanguageOptions = 256
+
+// getLanguageSwitch checks if src starts with a babel command that changes the language, like \selectlanguage{ngerman}.
+// If it is the case, it returns the kind of the command, the language and the number of bytes of the command.
+// It returns noSwitch if src does not start with such command, and needMore if src is too short to know.
+func getLanguageSwitch(src []byte) (sw languageSwitch, lang string, n int, needMore bool) {
+	for _, c := range languageCommands {
+		if hasPartialPrefix(src, c.prefix) {
+			return noSwitch, "", 0, true
+		}
+		if !bytes.HasPrefix(src, []byte(c.prefix)) {
+			continue
+		}
+		n = len(c.prefix)
+		if c.sw == endSwitch {
+			return endSwitch, "", n, false
+		}
+		closing := byte('}')
+		if c.sw == mainSwitch {
+			closing = ']'
+		}
+		i := bytes.IndexByte(src[n:], closing)
+		if i < 0 {
+			return noSwitch, "", 0, len(src) < n+maxLanguageOptions
+		}
+		lang = string(src[n : n+i])
+		n += i + 1
+		if c.sw != mainSwitch {
+			return c.sw, strings.TrimSpace(lang), n, false
+		}
+		const babel = "{babel}"
+		if hasPartialPrefix(src[n:], babel) {
+			return noSwitch, "", 0, true
+		}
+		if !bytes.HasPrefix(src[n:], []byte(babel)) {
+			return noSwitch, "", 0, false
+		}
+		return mainSwitch, mainLanguage(lang), n + len(babel), false
+	}
+	return noSwitch, "", 0, false
+}
+
+// mainLanguage returns the main language of the babel options, like ngerman for english,ngerman:
+// the main=... option or else the last language
+func mainLanguage(options string) (lang string) {
+	for _, o := range strings.Split(options, ",") {
+		o = strings.TrimSpace(o)
+		if name, ok := strings.CutPrefix(o, "main="); ok {
+			return strings.TrimSpace(name)
+		}
+		if o != "" && !strings.Contains(o, "=") {
+			lang = o
+		}
+	}
+	return lang
+}
+
+// switchLanguage changes the current language according to the babel command sw
+func (t *toUnicodeAccents) switchLanguage(sw languageSwitch, lang string) {
+	switch sw {
+	case selectSwitch, mainSwitch:
+		t.lang = lang
+	case beginSwitch:
+		t.langs = append(t.langs, t.lang)
+		t.lang = lang
+	case endSwitch:
+		if len(t.langs) > 0 {
+			t.lang = t.langs[len(t.langs)-1]
+			t.langs = t.langs[:len(t.langs)-1]
+		}
+	}
+}
+
+// shorthandTable returns the shorthands that are active here, or nil
+func (t *toUnicodeAccents) shorthandTable() map[string]string {
+	if t.mode != textMode || t.comment || !slices.Contains(t.shorthands, t.lang) {
+		return nil
+	}
+	return babelShorthands[t.lang]
+}
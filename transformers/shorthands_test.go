@@ -0,0 +1,83 @@
+package transformers
+
+import "testing"
+
+func TestGetShorthand(t *testing.T) {
+	data := []struct {
+		table   map[string]string
+		src     string
+		exps    string
+		expn    int
+		expMore bool
+	}{
+		{germanShorthands, "\"", "", 0, true},
+		{germanShorthands, "\"a", "ä", 2, false},
+		{germanShorthands, "\"ab", "ä", 2, false},
+		{germanShorthands, "\"s ", "ß", 2, false},
+		{germanShorthands, "\"`Hallo", "„", 2, false},
+		{germanShorthands, "\"\"x", "\u200B", 2, false},
+		{germanShorthands, "\"c", "", 0, true},
+		{germanShorthands, "\"ck", "ck", 3, false},
+		{germanShorthands, "\"cx", "", 0, false},
+		{germanShorthands, "\"x", "", 0, false},
+		{dutchShorthands, "\"i", "ï", 2, true},
+		{dutchShorthands, "\"ie", "ï", 2, false},
+		{dutchShorthands, "\"ij", "ij", 3, false},
+		{dutchShorthands, "\"I", "Ï", 2, true},
+	}
+
+	for i, d := range data {
+		s, n, more := getShorthand(d.table, []byte(d.src))
+		if s != d.exps {
+			t.Errorf("test %d: expected s=%q, got s=%q", i, d.exps, s)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGetLanguageSwitch(t *testing.T) {
+	data := []struct {
+		src     string
+		expsw   languageSwitch
+		explang string
+		expn    int
+		expMore bool
+	}{
+		{"\\selectlanguage{ngerman}x", selectSwitch, "ngerman", 24, false},
+		{"\\selectlanguage{ngerman", noSwitch, "", 0, true},
+		{"\\select", noSwitch, "", 0, true},
+		{"\\begin{otherlanguage}{dutch} ", beginSwitch, "dutch", 28, false},
+		{"\\begin{otherlanguage*}{dutch}", beginSwitch, "dutch", 29, false},
+		{"\\end{otherlanguage}", endSwitch, "", 19, false},
+		{"\\end{otherlanguage*} ", endSwitch, "", 20, false},
+		{"\\usepackage[english,ngerman]{babel}", mainSwitch, "ngerman", 35, false},
+		{"\\usepackage[main=ngerman, english]{babel}", mainSwitch, "ngerman", 41, false},
+		{"\\usepackage[ngerman, shorthands=off]{babel}", mainSwitch, "ngerman", 43, false},
+		{"\\usepackage[english]{bab", noSwitch, "", 0, true},
+		{"\\usepackage[T1]{fontenc}", noSwitch, "", 0, false},
+		{"\\usepackage{babel}", noSwitch, "", 0, false},
+		{"\\begin{center}", noSwitch, "", 0, false},
+		{"\\emph{x}", noSwitch, "", 0, false},
+	}
+
+	for i, d := range data {
+		sw, lang, n, more := getLanguageSwitch([]byte(d.src))
+		if sw != d.expsw {
+			t.Errorf("test %d: expected sw=%d, got sw=%d", i, d.expsw, sw)
+		}
+		if lang != d.explang {
+			t.Errorf("test %d: expected lang=%q, got lang=%q", i, d.explang, lang)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package transformers
+
+import "strings"
+
+// unicodeSpecialsToLaTeX is the mapping of the LaTeX special characters
+// to their escaped forms, used when the input is plain text
+var unicodeSpecialsToLaTeX = map[rune]string{
+	'&':  "\\&",
+	'%':  "\\%",
+	'$':  "\\$",
+	'#':  "\\#",
+	'_':  "\\_",
+	'{':  "\\{",
+	'}':  "\\}",
+	'~':  "\\textasciitilde{}",
+	'^':  "\\textasciicircum{}",
+	'\\': "\\textbackslash{}",
+}
+
+// latexSpecialsToUnicode is the mapping of the escaped LaTeX special characters
+// (the macro names without `\`, like & or textbackslash) to the characters
+var latexSpecialsToUnicode = specialsByName()
+
+// specialsByName returns the mapping of the escaped special characters names to the characters
+func specialsByName() map[string]rune {
+	m := make(map[string]rune)
+	for r, s := range unicodeSpecialsToLaTeX {
+		m[strings.TrimSuffix(s[1:], "{}")] = r
+	}
+	return m
+}
@@ -0,0 +1,19 @@
+package transformers
+
+// AccentArgs is the style of the arguments of the text accents when converting to LaTeX
+type AccentArgs string
+
+const (
+	AutoAccentArgs     AccentArgs = "auto"     // the letter accents are braced, like \'e and \c{c}
+	BracedAccentArgs   AccentArgs = "braced"   // all the accents are braced, like \'{e} and \c{c}
+	UnbracedAccentArgs AccentArgs = "unbraced" // no accent is braced, like \'e and \c c
+)
+
+// Terminator is the style of the end of the letter macros, like \ss, when converting to LaTeX
+type Terminator string
+
+const (
+	GroupTerminator Terminator = "group" // the macro is in a group, like {\ss}
+	EmptyTerminator Terminator = "empty" // the macro is followed by an empty group, like \ss{}
+	SpaceTerminator Terminator = "space" // the macro is followed by a space (or {} before a space) if needed, like \ss
+)
@@ -0,0 +1,213 @@
+package transformers
+
+// symbol is a Unicode character with its LaTeX macro names,
+// the first name is used to convert from Unicode to LaTeX.
+type symbol struct {
+	utf8  rune
+	names []string
+}
+
+// mathSymbols are the greek letters and the math symbols
+var mathSymbols = []symbol{
+	// Greek lowercase letters
+	{'α', []string{"alpha"}},
+	{'β', []string{"beta"}},
+	{'γ', []string{"gamma"}},
+	{'δ', []string{"delta"}},
+	{'ϵ', []string{"epsilon"}},
+	{'ε', []string{"varepsilon"}},
+	{'ζ', []string{"zeta"}},
+	{'η', []string{"eta"}},
+	{'θ', []string{"theta"}},
+	{'ϑ', []string{"vartheta"}},
+	{'ι', []string{"iota"}},
+	{'κ', []string{"kappa"}},
+	{'ϰ', []string{"varkappa"}},
+	{'λ', []string{"lambda"}},
+	{'μ', []string{"mu"}},
+	{'ν', []string{"nu"}},
+	{'ξ', []string{"xi"}},
+	{'π', []string{"pi"}},
+	{'ϖ', []string{"varpi"}},
+	{'ρ', []string{"rho"}},
+	{'ϱ', []string{"varrho"}},
+	{'σ', []string{"sigma"}},
+	{'ς', []string{"varsigma"}},
+	{'τ', []string{"tau"}},
+	{'υ', []string{"upsilon"}},
+	{'ϕ', []string{"phi"}},
+	{'φ', []string{"varphi"}},
+	{'χ', []string{"chi"}},
+	{'ψ', []string{"psi"}},
+	{'ω', []string{"omega"}},
+	// Greek uppercase letters (the others are Latin letters)
+	{'Γ', []string{"Gamma"}},
+	{'Δ', []string{"Delta"}},
+	{'Θ', []string{"Theta"}},
+	{'Λ', []string{"Lambda"}},
+	{'Ξ', []string{"Xi"}},
+	{'Π', []string{"Pi"}},
+	{'Σ', []string{"Sigma"}},
+	{'Υ', []string{"Upsilon"}},
+	{'Φ', []string{"Phi"}},
+	{'Ψ', []string{"Psi"}},
+	{'Ω', []string{"Omega"}},
+	// Relations
+	{'≤', []string{"leq", "le"}},
+	{'≥', []string{"geq", "ge"}},
+	{'≠', []string{"neq", "ne"}},
+	{'≮', []string{"nless"}},
+	{'≯', []string{"ngtr"}},
+	{'≰', []string{"nleq"}},
+	{'≱', []string{"ngeq"}},
+	{'≪', []string{"ll"}},
+	{'≫', []string{"gg"}},
+	{'≈', []string{"approx"}},
+	{'≡', []string{"equiv"}},
+	{'∼', []string{"sim"}},
+	{'≃', []string{"simeq"}},
+	{'≅', []string{"cong"}},
+	{'∝', []string{"propto"}},
+	{'≺', []string{"prec"}},
+	{'≻', []string{"succ"}},
+	{'⊂', []string{"subset"}},
+	{'⊃', []string{"supset"}},
+	{'⊆', []string{"subseteq"}},
+	{'⊇', []string{"supseteq"}},
+	{'∈', []string{"in"}},
+	{'∉', []string{"notin"}},
+	{'∋', []string{"ni"}},
+	{'⊥', []string{"perp"}},
+	{'∥', []string{"parallel"}},
+	{'∣', []string{"mid"}},
+	{'⊢', []string{"vdash"}},
+	{'⊨', []string{"models"}},
+	// Arrows
+	{'→', []string{"to", "rightarrow"}},
+	{'←', []string{"leftarrow", "gets"}},
+	{'↔', []string{"leftrightarrow"}},
+	{'↑', []string{"uparrow"}},
+	{'↓', []string{"downarrow"}},
+	{'⇒', []string{"Rightarrow"}},
+	{'⇐', []string{"Leftarrow"}},
+	{'⇔', []string{"Leftrightarrow"}},
+	{'↦', []string{"mapsto"}},
+	{'⟶', []string{"longrightarrow"}},
+	{'⟵', []string{"longleftarrow"}},
+	{'⟹', []string{"implies", "Longrightarrow"}},
+	{'⟸', []string{"impliedby", "Longleftarrow"}},
+	{'⟺', []string{"iff", "Longleftrightarrow"}},
+	// Operators
+	{'∑', []string{"sum"}},
+	{'∏', []string{"prod"}},
+	{'∐', []string{"coprod"}},
+	{'∫', []string{"int"}},
+	{'∬', []string{"iint"}},
+	{'∮', []string{"oint"}},
+	{'∪', []string{"cup"}},
+	{'∩', []string{"cap"}},
+	{'∖', []string{"setminus"}},
+	{'×', []string{"times"}},
+	{'÷', []string{"div"}},
+	{'±', []string{"pm"}},
+	{'∓', []string{"mp"}},
+	{'⋅', []string{"cdot"}},
+	{'∘', []string{"circ"}},
+	{'∙', []string{"bullet"}},
+	{'⊕', []string{"oplus"}},
+	{'⊗', []string{"otimes"}},
+	{'⊙', []string{"odot"}},
+	{'∗', []string{"ast"}},
+	{'⋆', []string{"star"}},
+	{'∧', []string{"wedge", "land"}},
+	{'∨', []string{"vee", "lor"}},
+	{'¬', []string{"neg", "lnot"}},
+	// Miscellaneous
+	{'∞', []string{"infty"}},
+	{'∀', []string{"forall"}},
+	{'∃', []string{"exists"}},
+	{'∄', []string{"nexists"}},
+	{'∅', []string{"emptyset", "varnothing"}},
+	{'∂', []string{"partial"}},
+	{'∇', []string{"nabla"}},
+	{'√', []string{"surd"}},
+	{'∠', []string{"angle"}},
+	{'ℵ', []string{"aleph"}},
+	{'ℏ', []string{"hbar"}},
+	{'ℓ', []string{"ell"}},
+	{'ℜ', []string{"Re"}},
+	{'ℑ', []string{"Im"}},
+	{'℘', []string{"wp"}},
+	{'′', []string{"prime"}},
+	{'⋯', []string{"cdots"}},
+	{'⋮', []string{"vdots"}},
+	{'⋱', []string{"ddots"}},
+	{'⟨', []string{"langle"}},
+	{'⟩', []string{"rangle"}},
+	{'⌊', []string{"lfloor"}},
+	{'⌋', []string{"rfloor"}},
+	{'⌈', []string{"lceil"}},
+	{'⌉', []string{"rceil"}},
+}
+
+// textSymbols are the textcomp (LICR) text symbols
+var textSymbols = []symbol{
+	{'€', []string{"texteuro"}},
+	{'£', []string{"pounds", "textsterling"}},
+	{'¢', []string{"textcent"}},
+	{'¥', []string{"textyen"}},
+	{'©', []string{"copyright", "textcopyright"}},
+	{'®', []string{"textregistered"}},
+	{'™', []string{"texttrademark"}},
+	{'°', []string{"textdegree"}},
+	{'§', []string{"S", "textsection"}},
+	{'¶', []string{"P", "textparagraph"}},
+	{'†', []string{"dag", "textdagger"}},
+	{'‡', []string{"ddag", "textdaggerdbl"}},
+	{'•', []string{"textbullet"}},
+	{'±', []string{"textpm"}},
+	{'×', []string{"texttimes"}},
+	{'÷', []string{"textdiv"}},
+	{'µ', []string{"textmu"}},
+	{'‰', []string{"textperthousand"}},
+	{'¼', []string{"textonequarter"}},
+	{'½', []string{"textonehalf"}},
+	{'¾', []string{"textthreequarters"}},
+	{'ª', []string{"textordfeminine"}},
+	{'º', []string{"textordmasculine"}},
+	{'¿', []string{"textquestiondown"}},
+	{'¡', []string{"textexclamdown"}},
+}
+
+// latexTextSymbols is the LaTeX to Unicode mapping of the text symbols,
+// they are treated as the special letters (like \ss)
+var latexTextSymbols = symbolsByName(textSymbols, latexSpecialLetter)
+
+// unicodeTextSymbolsToLaTeX is the Unicode to LaTeX mapping of the text symbols
+var unicodeTextSymbolsToLaTeX = symbolsByRune(textSymbols)
+
+// latexSymbols is the LaTeX to Unicode mapping of the math symbols
+var latexSymbols = symbolsByName(mathSymbols, latexSpecialSymbol)
+
+// unicodeSymbolsToLaTeX is the Unicode to LaTeX mapping of the math symbols
+var unicodeSymbolsToLaTeX = symbolsByRune(mathSymbols)
+
+// symbolsByName returns the LaTeX to Unicode mapping of the symbols
+func symbolsByName(symbols []symbol, spType latexSpecialType) map[string]latexSpecial {
+	m := make(map[string]latexSpecial)
+	for _, s := range symbols {
+		for _, name := range s.names {
+			m[name] = latexSpecial{spType, s.utf8}
+		}
+	}
+	return m
+}
+
+// symbolsByRune returns the Unicode to LaTeX mapping of the symbols (using their first name)
+func symbolsByRune(symbols []symbol) map[rune]string {
+	m := make(map[rune]string)
+	for _, s := range symbols {
+		m[s.utf8] = "\\" + s.names[0]
+	}
+	return m
+}
@@ -1,205 +1,722 @@
-package transformers
-
-import (
-	"fmt"
-	"strings"
-	"unicode/utf8"
-
-	"golang.org/x/text/transform"
-)
-
-// toLaTeXAccents is a transformer that converts Unicode diacritics to LaTeX accents
-type toLaTeXAccents struct {
-	letter  rune
-	accents []rune
-}
-
-// ToLaTeXAccents returns a transformer that converts Unicode diacritics to LaTeX accents
-func ToLaTeXAccents() transform.Transformer {
-	return &toLaTeXAccents{}
-}
-
-// Reset resets the transformer
-func (t *toLaTeXAccents) Reset() {
-	t.letter = 0
-	t.accents = t.accents[:0]
-}
-
-// unicodeAccentsToLaTeX is a unicode to LaTeX accent mapping
-// every diacritic is mapped to its LaTeX accent
-var unicodeAccentsToLaTeX = map[rune]rune{
-	0x300: '`',  // grave : à
-	0x301: '\'', // acute : á
-	0x302: '^',  // circumflex : â
-	0x303: '~',  // tilde : ã
-	0x304: '=',  // macron : ā
-	0x306: 'u',  // breve : ă
-	0x307: '.',  // dot-over : ġ
-	0x308: '"',  // two dots (umlaut, diaeresis) : ä
-	0x30A: 'r',  // ring : å
-	0x30B: 'H',  // double acute (long Hungarian umlaut) : ő
-	0x30C: 'v',  // háček : č
-	0x323: 'd',  // dot-under : ẹ
-	0x327: 'c',  // cedilla : ç
-	0x328: 'k',  // ogonek : ą
-	0x331: 'b',  // bar-under (macron below) : ḵ
-}
-
-// unicodeLettersToLaTeX is a unicode to LaTeX letter mapping
-// every letter is mapped to its LaTeX equivalent
-var unicodeLettersToLaTeX = map[rune]string{
-	'Ł': "{\\L}",
-	'ł': "{\\l}",
-	'Ø': "{\\O}",
-	'ø': "{\\o}",
-	'ı': "{\\i}",
-	'ȷ': "{\\j}",
-	'Å': "{\\AA}",
-	'å': "{\\aa}",
-	'Æ': "{\\AE}",
-	'æ': "{\\ae}",
-	'Œ': "{\\OE}",
-	'œ': "{\\oe}",
-	'ß': "{\\ss}",
-}
-
-type adjusment struct {
-	fromAccent rune
-	fromLetter rune
-	toAccent   rune
-	toLetter   rune
-}
-
-var adjustments = []adjusment{
-	{'`', 'i', '`', 'ı'},
-	{'\'', 'i', '\'', 'ı'},
-	{'^', 'i', '^', 'ı'},
-	{'"', 'i', '"', 'ı'},
-	{'`', 'j', '`', 'ȷ'},
-	{'\'', 'j', '\'', 'ȷ'},
-	{'^', 'j', '^', 'ȷ'},
-	{'"', 'j', '"', 'ȷ'},
-	{'"', 'j', '"', 'ȷ'},
-	{'r', 'a', 0, 'å'},
-	{'r', 'A', 0, 'Å'},
-}
-
-var adjustLetters = "ijaA"
-
-// adjust adjusts the accents and letter according to the adjustments table
-func (t *toLaTeXAccents) adjust() {
-	if len(t.accents) == 0 || strings.IndexRune(adjustLetters, t.letter) < 0 {
-		return
-	}
-	for _, a := range adjustments {
-		if a.fromAccent == t.accents[0] && a.fromLetter == t.letter {
-			if a.toAccent == 0 {
-				t.accents = t.accents[1:]
-			} else {
-				t.accents[0] = a.toAccent
-			}
-			t.letter = a.toLetter
-			return
-		}
-	}
-}
-
-// writeLaTeXRune writes r to dst and increments nDst by the number of bytes written
-// it returns true if the whole rune was written
-func writeLaTeXRune(dst []byte, r rune, nDst *int) bool {
-	if s, ok := unicodeLettersToLaTeX[r]; ok {
-		return write(dst, s, nDst)
-	}
-	return writeRune(dst, r, nDst)
-}
-
-// writeLaTeXLetter writes the LaTeX letter for a rune to dst
-// it returns true if the letter was written or if there is no letter to write (t.letter == 0)
-// if it returns false, nDst is not modified
-func (t *toLaTeXAccents) writeLaTeXLetter(dst []byte, nDst *int, inGroup bool) (done bool) {
-	if t.letter == 0 {
-		return true
-	}
-	// reset the letter if it was written
-	defer func() {
-		if done {
-			t.letter = 0
-		}
-	}()
-	if s, ok := unicodeLettersToLaTeX[t.letter]; ok {
-		return write(dst, s, nDst)
-	}
-	if inGroup {
-		return write(dst, fmt.Sprintf("{%c}", t.letter), nDst)
-	}
-	return writeLaTeXRune(dst, t.letter, nDst)
-}
-
-// writeLaTeXAccent writes the commulated LaTeX accents followed by the letter to dst
-// it returns true if everything was written
-// if it returns false, nDst is not modified
-func (t *toLaTeXAccents) writeLaTeXAccent(dst []byte, nDst *int) (done bool) {
-	n := *nDst
-	inGroup := false
-	// adjust the accents and letter
-	t.adjust()
-	// write the accents
-	for i := len(t.accents) - 1; i >= 0; i-- {
-		if !writeRune(dst, '\\', &n) || !writeRune(dst, t.accents[i], &n) {
-			return false
-		}
-		inGroup = isLatin(t.accents[i])
-	}
-	// write the letter (and reset it)
-	if !t.writeLaTeXLetter(dst, &n, inGroup) {
-		return false
-	}
-	// reset the accents
-	t.accents = t.accents[:0]
-	// everything was written
-	*nDst = n
-	return true
-}
-
-// Transform converts Unicode diacritics to LaTeX accents
-// src is supposed to be a valid UTF-8 string in NFD form
-func (t *toLaTeXAccents) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
-	// prev is the previous rune in src (of size psize)
-	// it is checked to see if it is a diacritic for the current rune
-	var (
-		r    rune // current rune (temp variable)
-		size int  // size of the current rune (in bytes, temp variable)
-	)
-	// loop over the runes in src
-	for nSrc < len(src) {
-		// read the next rune
-		r, size = utf8.DecodeRune(src[nSrc:])
-		if r == utf8.RuneError {
-			err := transform.ErrShortSrc
-			if atEOF {
-				err = fmt.Errorf("invalid UTF-8 encoding")
-			}
-			return nDst, nSrc, err
-		}
-		// check if the rune is a diacritic
-		if accent, ok := unicodeAccentsToLaTeX[r]; ok {
-			t.accents = append(t.accents, accent)
-		} else {
-			// write commulated accents followed by the letter
-			if !t.writeLaTeXAccent(dst, &nDst) {
-				return nDst, nSrc, transform.ErrShortDst
-			}
-			// save the current rune as the letter for the next accents (if any)
-			t.letter = r
-		}
-		nSrc += size
-	}
-	if len(t.accents) > 0 && !atEOF {
-		// we are collecting accents, but we reached the end of src
-		return nDst, nSrc, transform.ErrShortSrc
-	}
-	if !t.writeLaTeXAccent(dst, &nDst) {
-		return nDst, nSrc, transform.ErrShortDst
-	}
-	return nDst, nSrc, nil
-}
+package transformers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// toLaTeXAccents is a transformer that converts Unicode diacritics to LaTeX accents
+type toLaTeXAccents struct {
+	options
+	letter   rune
+	second   rune // the second letter of a double accent, like the s of \t{ts}
+	accents  []rune
+	mode     texMode
+	wrapOpen bool   // a $...$ (or \ensuremath{...}) around symbols in text mode is open
+	group    string // the opening of the open group of letters, like \mathbb{
+	csEnd    bool   // the last written thing is a control word
+	unmapped []rune // the characters without LaTeX form to report (with ErrorFallback)
+}
+
+// ToLaTeXAccents returns a transformer that converts Unicode diacritics to LaTeX accents.
+// The content of the verbatim-like regions, and by default of the math, is passed through untouched.
+// With WithEscapeSpecials the input is plain text and its special characters are escaped.
+// With WithBibFields the input is a .bib file and only the selected fields are converted.
+func ToLaTeXAccents(opts ...Option) transform.Transformer {
+	o := newOptions(opts...)
+	if o.bibFields != nil {
+		// the `"` of the accents should not end the quoted values
+		o.charGroups = true
+	}
+	var t transform.Transformer
+	switch {
+	case o.escape && o.quotes == FrenchQuotes:
+		// the plain text has no regions, all of it is in text mode
+		t = transform.Chain(&frenchSpacing{}, unmappedLink(&toLaTeXAccents{options: *o}, o))
+	case o.escape:
+		t = &toLaTeXAccents{options: *o}
+	case o.quotes == FrenchQuotes:
+		t = transform.Chain(newTexRegions(&frenchSpacing{}, o), unmappedLink(newTexRegions(&toLaTeXAccents{options: *o}, o), o))
+	default:
+		t = newTexRegions(&toLaTeXAccents{options: *o}, o)
+	}
+	if o.bibFields != nil {
+		t = newBibFields(t, o)
+	}
+	if o.fallback == ErrorFallback && o.quotes != FrenchQuotes {
+		t = &unmappedChars{inner: t}
+	}
+	return t
+}
+
+// unmappedLink collects the characters without LaTeX form in the link of a chain,
+// as the chain stops at the first error (with ErrorFallback)
+func unmappedLink(t transform.Transformer, o *options) transform.Transformer {
+	if o.fallback != ErrorFallback {
+		return t
+	}
+	return &unmappedChars{inner: t}
+}
+
+// Reset resets the transformer
+func (t *toLaTeXAccents) Reset() {
+	t.letter = 0
+	t.second = 0
+	t.accents = t.accents[:0]
+	t.wrapOpen = false
+	t.group = ""
+	t.csEnd = false
+	t.unmapped = t.unmapped[:0]
+}
+
+// setMode sets the TeX mode of the text to transform
+func (t *toLaTeXAccents) setMode(m texMode) {
+	t.mode = m
+}
+
+// unicodeAccentsToLaTeX is a unicode to LaTeX accent mapping
+// every diacritic is mapped to its LaTeX accent
+var unicodeAccentsToLaTeX = map[rune]string{
+	0x300: "`",                // grave : à
+	0x301: "'",                // acute : á
+	0x302: "^",                // circumflex : â
+	0x303: "~",                // tilde : ã
+	0x304: "=",                // macron : ā
+	0x306: "u",                // breve : ă
+	0x307: ".",                // dot-over : ġ
+	0x308: "\"",               // two dots (umlaut, diaeresis) : ä
+	0x309: "h",                // hook above (vntex) : ả
+	0x30A: "r",                // ring : å
+	0x30B: "H",                // double acute (long Hungarian umlaut) : ő
+	0x30C: "v",                // háček : č
+	0x30E: "U",                // double vertical line above : a̎
+	0x30F: "G",                // double grave : ȁ
+	0x311: "f",                // inverted breve : ȃ
+	0x323: "d",                // dot-under : ẹ
+	0x325: "textsubring",      // ring below : ḁ
+	0x326: "textcommabelow",   // comma below : ș
+	0x327: "c",                // cedilla : ç
+	0x328: "k",                // ogonek : ą
+	0x331: "b",                // bar-under (macron below) : ḵ
+	0x35C: "textbottomtiebar", // tie below (tipa) : t͜s
+	0x361: "t",                // tie (double inverted breve) : t͡s
+}
+
+// spacingAccents are the spacing forms of the diacritics, written as accents with an empty argument, like \'{}
+var spacingAccents = map[rune]rune{
+	0x2CB: 0x300, // modifier grave : ˋ
+	0xB4:  0x301, // acute : ´
+	0x2C6: 0x302, // modifier circumflex : ˆ
+	0x2DC: 0x303, // small tilde : ˜
+	0xAF:  0x304, // macron : ¯
+	0x2D8: 0x306, // breve : ˘
+	0x2D9: 0x307, // dot above : ˙
+	0xA8:  0x308, // diaeresis : ¨
+	0x2DA: 0x30A, // ring above : ˚
+	0x2DD: 0x30B, // double acute : ˝
+	0x2C7: 0x30C, // caron : ˇ
+	0xB8:  0x327, // cedilla : ¸
+	0x2DB: 0x328, // ogonek : ˛
+	0x2CD: 0x331, // modifier low macron : ˍ
+}
+
+// unicodeMathAccentsToLaTeX is a unicode to LaTeX math accent mapping
+// it is used in math mode only
+var unicodeMathAccentsToLaTeX = map[rune]string{
+	0x300:  "grave",    // grave : \grave{a}
+	0x301:  "acute",    // acute : \acute{a}
+	0x302:  "hat",      // circumflex : \hat{x}
+	0x303:  "tilde",    // tilde : \tilde{n}
+	0x304:  "bar",      // macron : \bar{a}
+	0x306:  "breve",    // breve : \breve{a}
+	0x307:  "dot",      // dot-over : \dot{x}
+	0x308:  "ddot",     // two dots : \ddot{x}
+	0x30A:  "mathring", // ring : \mathring{a}
+	0x30C:  "check",    // háček : \check{c}
+	0x20D7: "vec",      // right arrow above : \vec{v}
+	0x20DB: "dddot",    // three dots : \dddot{x}
+	0x20DC: "ddddot",   // four dots : \ddddot{x}
+}
+
+// isAccent returns true if r is a diacritic that can be converted in the current mode
+func (t *toLaTeXAccents) isAccent(r rune) bool {
+	if _, ok := unicodeAccentsToLaTeX[r]; ok {
+		return true
+	}
+	if r == notMark && t.symbols {
+		return true
+	}
+	if t.mode == mathMode {
+		_, ok := unicodeMathAccentsToLaTeX[r]
+		return ok
+	}
+	// the other diacritics are kept with their letter (in its group, like in BibTeX)
+	return unicode.Is(unicode.Mn, r)
+}
+
+// unicodeLettersToLaTeX is a unicode to LaTeX letter mapping
+// every letter is mapped to its LaTeX macro (written with the terminator style, like {\ss})
+var unicodeLettersToLaTeX = map[rune]string{
+	'Ł': "\\L",
+	'ł': "\\l",
+	'Ø': "\\O",
+	'ø': "\\o",
+	'ı': "\\i",
+	'ȷ': "\\j",
+	'Å': "\\AA",
+	'å': "\\aa",
+	'Æ': "\\AE",
+	'æ': "\\ae",
+	'Œ': "\\OE",
+	'œ': "\\oe",
+	'ß': "\\ss",
+	'ẞ': "\\SS",
+	'Ð': "\\DH",
+	'ð': "\\dh",
+	'Þ': "\\TH",
+	'þ': "\\th",
+	'Đ': "\\DJ",
+	'đ': "\\dj",
+	'Ŋ': "\\NG",
+	'ŋ': "\\ng",
+	'Ĳ': "\\IJ",
+	'ĳ': "\\ij",
+	'Ħ': "\\textHbar",
+	'ħ': "\\texthbar",
+	'Ŀ': "\\Ldot",
+	'ŀ': "\\ldot",
+	'ĸ': "\\textkra",
+}
+
+type adjusment struct {
+	fromAccent rune
+	fromLetter rune
+	toAccent   rune
+	toLetter   rune
+}
+
+var adjustments = []adjusment{
+	{0x30A, 'a', 0, 'å'},
+	{0x30A, 'A', 0, 'Å'},
+}
+
+var adjustLetters = "aA"
+
+// dotlessLetters are the letters that lose their dot under the accents above, like \'\i
+var dotlessLetters = map[rune]rune{
+	'i': 'ı',
+	'j': 'ȷ',
+}
+
+// isAboveMark returns true if r is a combining mark placed above the letter, like the acute accent
+func isAboveMark(r rune) bool {
+	return norm.NFD.PropertiesString(string(r)).CCC() == 230
+}
+
+// adjust adjusts the accents and letter according to the adjustments table
+// and uses the dotless i and j under the accents above
+func (t *toLaTeXAccents) adjust() {
+	if len(t.accents) == 0 {
+		return
+	}
+	if d, ok := dotlessLetters[t.letter]; ok && slices.ContainsFunc(t.accents, isAboveMark) {
+		t.letter = d
+		return
+	}
+	if strings.IndexRune(adjustLetters, t.letter) < 0 {
+		return
+	}
+	for _, a := range adjustments {
+		if a.fromAccent == t.accents[0] && a.fromLetter == t.letter {
+			if a.toAccent == 0 {
+				t.accents = t.accents[1:]
+			} else {
+				t.accents[0] = a.toAccent
+			}
+			t.letter = a.toLetter
+			return
+		}
+	}
+}
+
+// writeLaTeXRune writes r to dst and increments nDst by the number of bytes written
+// it returns true if the whole rune was written
+func writeLaTeXRune(dst []byte, r rune, nDst *int) bool {
+	if s, ok := unicodeLettersToLaTeX[r]; ok {
+		return write(dst, "{"+s+"}", nDst)
+	}
+	return writeRune(dst, r, nDst)
+}
+
+// letterMacro returns the LaTeX macro of the letter, like \ss or \S, if there is one
+func (t *toLaTeXAccents) letterMacro() (string, bool) {
+	if s, ok := unicodeLettersToLaTeX[t.letter]; ok {
+		return s, true
+	}
+	if s, ok := unicodeTextSymbolsToLaTeX[t.letter]; ok && t.mode == textMode {
+		return s, true
+	}
+	return "", false
+}
+
+// latexLetter returns the LaTeX form of the letter, inGroup is true if it is the braced argument of an accent.
+// The letter macros without accent are written with the terminator style, like {\ss}, \ss{} or \ss.
+func (t *toLaTeXAccents) latexLetter(inGroup bool) string {
+	if t.letter == 0 {
+		return ""
+	}
+	if s, ok := t.letterMacro(); ok {
+		switch {
+		case inGroup:
+			return "{" + s + "}"
+		case len(t.accents) > 0:
+			return s
+		case t.charGroups || (t.terminator != EmptyTerminator && t.terminator != SpaceTerminator):
+			return "{" + s + "}"
+		case t.terminator == EmptyTerminator:
+			return s + "{}"
+		}
+		return s
+	}
+	s := string(t.letter)
+	if e, ok := unicodeSpecialsToLaTeX[t.letter]; ok && t.escape {
+		s = e
+	} else if t.letter >= utf8.RuneSelf && t.mode == textMode {
+		s = t.fallbackForm(t.letter)
+		if inGroup && t.fallback == CharFallback {
+			// the {\char"XXXX} form is already a group
+			return s
+		}
+	}
+	if inGroup {
+		return "{" + s + "}"
+	}
+	return s
+}
+
+// notMark is the combining long solidus overlay used to negate the symbols (like ≠)
+const notMark = 0x338
+
+// symbol returns the LaTeX macro for the letter (possibly negated) if it is a symbol to convert
+func (t *toLaTeXAccents) symbol() (string, bool) {
+	if !t.symbols || t.letter == 0 {
+		return "", false
+	}
+	if len(t.accents) == 0 {
+		if _, ok := unicodeTextSymbolsToLaTeX[t.letter]; ok && t.mode == textMode {
+			// the text symbols (like ±) are preferred in text mode
+			return "", false
+		}
+		s, ok := unicodeSymbolsToLaTeX[t.letter]
+		return s, ok
+	}
+	if len(t.accents) > 1 || t.accents[0] != notMark {
+		return "", false
+	}
+	// look for the negated symbol, like ≠
+	if r := []rune(norm.NFC.String(string([]rune{t.letter, notMark}))); len(r) == 1 {
+		if s, ok := unicodeSymbolsToLaTeX[r[0]]; ok {
+			return s, true
+		}
+	}
+	if s, ok := unicodeSymbolsToLaTeX[t.letter]; ok {
+		return "\\not" + s, true
+	}
+	return "\\not" + string(t.letter), true
+}
+
+// alphabetLetter returns the math alphabet letter, like \mathbb{R}, if it should be converted
+func (t *toLaTeXAccents) alphabetLetter() (alphabetLetter, bool) {
+	if !t.alphabets {
+		return alphabetLetter{}, false
+	}
+	a, ok := unicodeMathAlphabetsToLaTeX[t.letter]
+	return a, ok
+}
+
+// groupLetter returns the opening of the group and the character for the letters
+// that are written in groups, like \mathbb{R} or ^{2}, and true if the group needs math mode
+func (t *toLaTeXAccents) groupLetter() (open string, c rune, math bool) {
+	if len(t.accents) > 0 {
+		return "", 0, false
+	}
+	if a, ok := t.alphabetLetter(); ok {
+		return a.name + "{", a.letter, true
+	}
+	if s, ok := unicodeScriptsToLaTeX[t.letter]; ok && t.scripts {
+		return scriptGroup(s.script, t.mode), s.char, false
+	}
+	return "", 0, false
+}
+
+// quote returns the LaTeX form of the letter if it is a quotation mark to convert
+func (t *toLaTeXAccents) quote() (string, bool) {
+	if t.quotes == NoQuotes || t.mode != textMode || len(t.accents) > 0 {
+		return "", false
+	}
+	s, ok := unicodeQuotesToLaTeX[t.quotes][t.letter]
+	return s, ok
+}
+
+// dash returns the LaTeX form of the letter if it is a dash, an ellipsis or a special space to convert
+func (t *toLaTeXAccents) dash() (string, bool) {
+	if !t.dashes || len(t.accents) > 0 {
+		return "", false
+	}
+	s, ok := unicodeDashesToLaTeX[t.letter]
+	if !ok || (t.mode == mathMode && !isMathDash(t.letter)) {
+		return "", false
+	}
+	return s, true
+}
+
+// isControlWord returns true if s ends with a control word, like \glqq
+func isControlWord(s string) bool {
+	i := strings.LastIndexByte(s, '\\')
+	if i < 0 || i == len(s)-1 {
+		return false
+	}
+	for _, c := range []byte(s[i+1:]) {
+		if !isLatin(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// mathWrapper returns the string that opens (or closes) the math around symbols in text mode
+func (t *toLaTeXAccents) mathWrapper(open bool) string {
+	switch {
+	case t.ensureMath && open:
+		return "\\ensuremath{"
+	case t.ensureMath:
+		return "}"
+	}
+	return "$"
+}
+
+// writeLaTeXAccent writes the commulated LaTeX accents followed by the letter to dst
+// it returns true if everything was written
+// if it returns false, nDst is not modified
+func (t *toLaTeXAccents) writeLaTeXAccent(dst []byte, nDst *int) (done bool) {
+	if t.letter == 0 && len(t.accents) == 0 {
+		return true
+	}
+	n := *nDst
+	textCS := false
+	symbol, isSymbol := t.symbol()
+	// the runs of letters of the same alphabet (or script) are written in one group, like \mathbb{NZ}
+	var (
+		group     string
+		groupChar rune
+		groupMath bool
+	)
+	if !isSymbol {
+		group, groupChar, groupMath = t.groupLetter()
+	}
+	// the quotes, the dashes and the special spaces are written as they are
+	punct, isPunct := t.quote()
+	if !isPunct {
+		punct, isPunct = t.dash()
+	}
+	if t.group != "" && group != t.group {
+		if !writeByte(dst, '}', &n) {
+			return false
+		}
+	}
+	// the symbols and the alphabet letters in text mode are written inside $...$ (or \ensuremath{...})
+	wrap := (isSymbol || groupMath) && t.mode == textMode
+	if wrap != t.wrapOpen {
+		if !write(dst, t.mathWrapper(wrap), &n) {
+			return false
+		}
+	} else if _, isMacro := t.letterMacro(); t.csEnd && !isSymbol && !isMacro && len(t.accents) == 0 && unicode.IsLetter(t.letter) {
+		// the previous control word should not be followed by a letter
+		if !writeByte(dst, ' ', &n) {
+			return false
+		}
+	} else if t.csEnd && !isPunct && t.mode == textMode && unicode.IsSpace(t.letter) {
+		// the space after the previous control word should not be gobbled
+		if !write(dst, "{}", &n) {
+			return false
+		}
+	}
+	if group != "" && group != t.group {
+		if !write(dst, group, &n) {
+			return false
+		}
+	}
+	switch {
+	case isPunct:
+		if !write(dst, punct, &n) {
+			return false
+		}
+		t.letter = 0
+	case group != "":
+		if !writeRune(dst, groupChar, &n) {
+			return false
+		}
+		t.letter = 0
+	case isSymbol:
+		if !write(dst, symbol, &n) {
+			return false
+		}
+		t.letter = 0
+		t.accents = t.accents[:0]
+	case t.mode == mathMode && len(t.accents) > 0:
+		if !t.writeMathAccent(dst, &n) {
+			return false
+		}
+	default:
+		s := t.textAccent()
+		if !write(dst, s, &n) {
+			return false
+		}
+		t.letter = 0
+		t.second = 0
+		t.accents = t.accents[:0]
+		// the letter macros can be written without terminator, like \ss
+		textCS = isControlWord(s)
+	}
+	t.wrapOpen = wrap
+	t.group = group
+	t.csEnd = isSymbol || (isPunct && isControlWord(punct)) || textCS
+	*nDst = n
+	return true
+}
+
+// closeGroups closes the open group of letters and the math around symbols in text mode
+func (t *toLaTeXAccents) closeGroups(dst []byte, nDst *int) bool {
+	if t.group != "" {
+		if !writeByte(dst, '}', nDst) {
+			return false
+		}
+		t.group = ""
+	}
+	if t.wrapOpen {
+		if !write(dst, t.mathWrapper(false), nDst) {
+			return false
+		}
+		t.wrapOpen = false
+	}
+	t.csEnd = false
+	return true
+}
+
+// isSecondLetter returns true if r is the second letter of the double accent on the letter, like the s of t͡s
+func (t *toLaTeXAccents) isSecondLetter(r rune) bool {
+	if t.mode != textMode || len(t.accents) != 1 || !isDoubleAccent(t.accents[0]) || !unicode.IsLetter(t.letter) {
+		return false
+	}
+	if _, ok := unicodeAccentsToLaTeX[t.accents[0]]; !ok {
+		return false
+	}
+	_, isMacro := unicodeLettersToLaTeX[r]
+	return unicode.IsLetter(r) && !isMacro
+}
+
+// textAccent returns the commulated accents followed by the letter as text accents, like \'e,
+// in the accent arguments style, and in a group if the characters are grouped, like {\'e}
+func (t *toLaTeXAccents) textAccent() string {
+	// adjust the accents and letter
+	t.adjust()
+	var (
+		b       strings.Builder
+		closing string
+		inGroup bool
+		accents []rune // the accents with a LaTeX macro
+		marks   string // the other diacritics, written after the letter
+	)
+	for _, a := range t.accents {
+		if _, ok := unicodeAccentsToLaTeX[a]; ok {
+			accents = append(accents, a)
+		} else {
+			marks += t.fallbackForm(a)
+		}
+	}
+	_, isMacro := t.letterMacro()
+	for i := len(accents) - 1; i >= 0; i-- {
+		accent := unicodeAccentsToLaTeX[accents[i]]
+		b.WriteString("\\" + accent)
+		if i > 0 {
+			// the argument is the next accent, like \c{\'e}
+			if t.accentArgs == BracedAccentArgs || isLatin(accent[0]) {
+				b.WriteByte('{')
+				closing += "}"
+			}
+			continue
+		}
+		// the argument is the letter
+		inGroup = t.accentArgs == BracedAccentArgs || t.second != 0 || t.letter == 0 ||
+			(t.accentArgs != UnbracedAccentArgs && (isLatin(accent[0]) || isMacro))
+		if !inGroup && isLatin(accent[0]) && !isMacro && t.letter != 0 {
+			// the letter accent is followed by a space, like \c c
+			b.WriteByte(' ')
+		}
+	}
+	if t.second != 0 {
+		// the double accent spans both letters, like \t{ts}
+		second := string(t.second)
+		if t.second >= utf8.RuneSelf {
+			second = t.fallbackForm(t.second)
+		}
+		first := t.latexLetter(false)
+		if isMacro && isLatin(second[0]) {
+			// the letter macro is followed by a space, like \t{\i u}
+			first += " "
+		}
+		b.WriteString("{" + first + second + "}")
+	} else if marks == "" && t.letter != 0 {
+		b.WriteString(t.latexLetter(inGroup))
+	} else if inGroup {
+		// the accents without letter have an empty argument, like \'{}
+		b.WriteString("{" + t.latexLetter(false) + marks + "}")
+	} else {
+		b.WriteString(t.latexLetter(false) + marks)
+	}
+	b.WriteString(closing)
+	if t.bibtex && len(accents) == 0 {
+		// a BibTeX special character group starts with a backslash, so the diacritics without LaTeX accent,
+		// like in ơ, are only written with the fallback, as the characters without LaTeX form
+		return b.String()
+	}
+	if t.charGroups && len(t.accents) > 0 {
+		return "{" + b.String() + "}"
+	}
+	return b.String()
+}
+
+// mathLetters are the letters that should be dotless under a math accent
+var mathLetters = map[rune]string{
+	'i': "\\imath",
+	'j': "\\jmath",
+	'ı': "\\imath",
+	'ȷ': "\\jmath",
+}
+
+// writeMathAccent writes the commulated accents as math accents, like \hat{x}, to dst
+// the accents without math equivalent are written as text accents
+// it returns true if everything was written
+// if it returns false, nDst is not modified
+func (t *toLaTeXAccents) writeMathAccent(dst []byte, nDst *int) (done bool) {
+	var b strings.Builder
+	for i := len(t.accents) - 1; i >= 0; i-- {
+		if name, ok := unicodeMathAccentsToLaTeX[t.accents[i]]; ok {
+			b.WriteString("\\" + name + "{")
+		} else {
+			b.WriteString("\\" + unicodeAccentsToLaTeX[t.accents[i]] + "{")
+		}
+	}
+	if s, ok := mathLetters[t.letter]; ok {
+		b.WriteString(s)
+	} else if s, ok := unicodeSymbolsToLaTeX[t.letter]; ok && t.symbols {
+		b.WriteString(s)
+	} else if a, ok := t.alphabetLetter(); ok {
+		b.WriteString(a.name + "{" + string(a.letter) + "}")
+	} else if s, ok := unicodeLettersToLaTeX[t.letter]; ok {
+		b.WriteString(s)
+	} else if t.letter != 0 {
+		b.WriteRune(t.letter)
+	}
+	b.WriteString(strings.Repeat("}", len(t.accents)))
+	if !write(dst, b.String(), nDst) {
+		return false
+	}
+	t.letter = 0
+	t.accents = t.accents[:0]
+	return true
+}
+
+// Transform converts Unicode diacritics to LaTeX accents
+// src is supposed to be a valid UTF-8 string in NFD form
+func (t *toLaTeXAccents) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	// prev is the previous rune in src (of size psize)
+	// it is checked to see if it is a diacritic for the current rune
+	var (
+		r    rune // current rune (temp variable)
+		size int  // size of the current rune (in bytes, temp variable)
+	)
+	// loop over the runes in src
+	for nSrc < len(src) {
+		// read the next rune
+		r, size = utf8.DecodeRune(src[nSrc:])
+		if r == utf8.RuneError {
+			err := transform.ErrShortSrc
+			if atEOF {
+				err = fmt.Errorf("invalid UTF-8 encoding")
+			}
+			return nDst, nSrc, err
+		}
+		// check if the rune is a diacritic
+		if t.isAccent(r) {
+			t.accents = append(t.accents, r)
+		} else if a, ok := spacingAccents[r]; ok && t.mode == textMode {
+			// the spacing accent is written with an empty argument, like \'{}
+			if !t.writeLaTeXAccent(dst, &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			if err := t.unmappedErr(); err != nil {
+				// r is read again on the next call
+				return nDst, nSrc, err
+			}
+			t.accents = append(t.accents, a)
+		} else if t.isSecondLetter(r) {
+			// the second letter has no diacritic if the next rune is not a combining mark
+			next := src[nSrc+size:]
+			if !utf8.FullRune(next) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			m, _ := utf8.DecodeRune(next)
+			if len(next) > 0 && unicode.Is(unicode.Mn, m) {
+				if !t.writeLaTeXAccent(dst, &nDst) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				if err := t.unmappedErr(); err != nil {
+					return nDst, nSrc, err
+				}
+				t.letter = r
+			} else {
+				t.second = r
+				if !t.writeLaTeXAccent(dst, &nDst) {
+					t.second = 0
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				if err := t.unmappedErr(); err != nil {
+					// r is already written
+					return nDst, nSrc + size, err
+				}
+			}
+		} else {
+			// write commulated accents followed by the letter
+			if !t.writeLaTeXAccent(dst, &nDst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			if err := t.unmappedErr(); err != nil {
+				return nDst, nSrc, err
+			}
+			// save the current rune as the letter for the next accents (if any)
+			t.letter = r
+		}
+		nSrc += size
+	}
+	if len(t.accents) > 0 && !atEOF {
+		// we are collecting accents, but we reached the end of src
+		return nDst, nSrc, transform.ErrShortSrc
+	}
+	if !t.writeLaTeXAccent(dst, &nDst) {
+		return nDst, nSrc, transform.ErrShortDst
+	}
+	if err := t.unmappedErr(); err != nil {
+		return nDst, nSrc, err
+	}
+	if atEOF && !t.closeGroups(dst, &nDst) {
+		return nDst, nSrc, transform.ErrShortDst
+	}
+	return nDst, nSrc, nil
+}
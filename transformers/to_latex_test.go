@@ -1,156 +1,548 @@
-package transformers
-
-import (
-	"bytes"
-	"testing"
-
-	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
-)
-
-func TestToLaTeXAccentsReset(t *testing.T) {
-	lat := &toLaTeXAccents{letter: 'a', accents: []rune{'`'}}
-	lat.Reset()
-	if lat.letter != 0 {
-		t.Errorf("expected letter=0, got letter=%v", lat.letter)
-	}
-	if len(lat.accents) != 0 {
-		t.Errorf("expected accents=nil, got accents=%v", lat.accents)
-	}
-}
-
-func TestWriteLaTeXRune(t *testing.T) {
-	data := []struct {
-		r      rune   // rune
-		dst    []byte // destination slice
-		ndst   int    // number of bytes already written to the destination slice
-		expdst []byte // expected destination slice after writing
-		expres bool   // expected return value of the write operation
-	}{
-		{'a', []byte("....."), 0, []byte("a...."), true},
-		{'a', []byte("....."), 4, []byte("....a"), true},
-		{'a', []byte("....."), 5, []byte("....."), false},
-		{'Ł', []byte("....."), 0, []byte("{\\L}."), true},
-		{'Ł', []byte("....."), 4, []byte("....{"), false},
-	}
-
-	for i, d := range data {
-		ok := writeLaTeXRune(d.dst, d.r, &d.ndst)
-		if ok != d.expres {
-			t.Errorf("test %d: expected ok=%v, got ok=%v", i, d.expres, ok)
-		}
-		if !bytes.Equal(d.dst, d.expdst) {
-			t.Errorf("test %d: expected dst=%s, got dst=%s", i, d.expdst, d.dst)
-		}
-	}
-}
-
-func TestWriteLaTeXLetter(t *testing.T) {
-	data := []struct {
-		letter  rune   // letter rune
-		dst     []byte // destination slice
-		ndst    int    // number of bytes already written to the destination slice
-		inGroup bool   // letter is in a group
-		exp     []byte // expected destination slice after writing
-		expn    int    // expected number of bytes written to the destination slice after writing
-		expok   bool   // expected return value of the write operation
-	}{
-		{'a', []byte("....."), 0, false, []byte("a...."), 1, true},
-		{'a', []byte("....."), 4, false, []byte("....a"), 5, true},
-		{'a', []byte("....."), 5, false, []byte("....."), 5, false},
-		{'a', []byte("....."), 0, true, []byte("{a}.."), 3, true},
-		{'a', []byte("....."), 2, true, []byte("..{a}"), 5, true},
-		{'a', []byte("....."), 3, true, []byte("...{a"), 3, false},
-		{'a', []byte("....."), 4, true, []byte("....{"), 4, false},
-		{'a', []byte("....."), 5, true, []byte("....."), 5, false},
-	}
-
-	for i, d := range data {
-		lat := &toLaTeXAccents{letter: d.letter}
-		ok := lat.writeLaTeXLetter(d.dst, &d.ndst, d.inGroup)
-		if ok != d.expok {
-			t.Errorf("test %d: expected ok=%v, got ok=%v", i, d.expok, ok)
-		}
-		if !bytes.Equal(d.dst, d.exp) {
-			t.Errorf("test %d: expected dst=%s, got dst=%s", i, d.exp, d.dst)
-		}
-		if d.ndst != d.expn {
-			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, d.ndst)
-		}
-	}
-}
-
-func TestWriteLaTeXAccent(t *testing.T) {
-	data := []struct {
-		dst    []byte // destination slice
-		acc    []rune // accent rune
-		letter rune   // letter rune
-		exp    []byte // expected destination slice after writing
-		ndst   int    // number of bytes already written to the destination slice
-		expn   int    // expected number of bytes written to the destination slice after writing
-		expok  bool   // expected return value of the write operation
-	}{
-		{[]byte("....."), []rune("`"), 'a', []byte("\\`a.."), 0, 3, true},
-		{[]byte("....."), []rune("`"), 'a', []byte(".\\`a."), 1, 4, true},
-		{[]byte("....."), []rune("`"), 'a', []byte("..\\`a"), 2, 5, true},
-		{[]byte("....."), []rune("`"), 'a', []byte("...\\`"), 3, 3, false},
-		{[]byte("....."), []rune("`"), 'a', []byte("....\\"), 4, 4, false},
-		{[]byte("....."), []rune("`"), 'a', []byte("....."), 5, 5, false},
-		{[]byte("....."), []rune("c"), 'c', []byte("\\c{c}"), 0, 5, true},
-		{[]byte("....."), []rune("c"), 'c', []byte(".\\c{c"), 1, 1, false},
-		{[]byte("....."), []rune("c"), 'c', []byte("..\\c{"), 2, 2, false},
-		{[]byte("....."), []rune("c"), 'c', []byte("...\\c"), 3, 3, false},
-		{[]byte("....."), []rune("c"), 'c', []byte("....\\"), 4, 4, false},
-		{[]byte("....."), []rune("c"), 'c', []byte("....."), 5, 5, false},
-		{[]byte("......."), []rune("`'"), 'a', []byte("\\'\\`a.."), 0, 5, true},
-		{[]byte("......."), []rune("`'^"), 'a', []byte("\\^\\'\\`a"), 0, 7, true},
-	}
-
-	for i, d := range data {
-		lat := &toLaTeXAccents{accents: d.acc, letter: d.letter}
-		ok := lat.writeLaTeXAccent(d.dst, &d.ndst)
-		if ok != d.expok {
-			t.Errorf("test %d: expected ok=%v, got ok=%v", i, d.expok, ok)
-		}
-		if !bytes.Equal(d.dst, d.exp) {
-			t.Errorf("test %d: expected dst=%s, got dst=%s", i, d.exp, d.dst)
-		}
-		if d.ndst != d.expn {
-			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, d.ndst)
-		}
-	}
-}
-
-func TestToLaTeXAccents(t *testing.T) {
-	data := []struct {
-		src string // source string
-		exp string // expected destination string after writing
-	}{
-		{"a", "a"},
-		{"ç", "\\c{c}"},
-		{"é", "\\'e"},
-		{"bêtâ", "b\\^et\\^a"},
-		{"ḵ", "\\b{k}"},
-		{"Ceci est œuf", "Ceci est {\\oe}uf"},
-	}
-
-	var buf bytes.Buffer
-	w := transform.NewWriter(&buf, ToLaTeXAccents())
-
-	for i, d := range data {
-		buf.Reset()
-		// convert d.src to NFD (required by ToLaTeXAccents)
-		src := norm.NFD.String(d.src)
-		_, err := w.Write([]byte(src))
-		if err != nil {
-			t.Errorf("test %d: unexpected error: %v", i, err)
-		}
-		err = w.Close()
-		if err != nil {
-			t.Errorf("test %d: unexpected error: %v", i, err)
-		}
-		if buf.String() != d.exp {
-			t.Errorf("test %d: expected dst=%v, got dst=%v", i, d.exp, buf.String())
-		}
-	}
-}
+package transformers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestToLaTeXAccentsReset(t *testing.T) {
+	lat := &toLaTeXAccents{letter: 'a', accents: []rune{0x300}}
+	lat.Reset()
+	if lat.letter != 0 {
+		t.Errorf("expected letter=0, got letter=%v", lat.letter)
+	}
+	if len(lat.accents) != 0 {
+		t.Errorf("expected accents=nil, got accents=%v", lat.accents)
+	}
+}
+
+func TestWriteLaTeXRune(t *testing.T) {
+	data := []struct {
+		r      rune   // rune
+		dst    []byte // destination slice
+		ndst   int    // number of bytes already written to the destination slice
+		expdst []byte // expected destination slice after writing
+		expres bool   // expected return value of the write operation
+	}{
+		{'a', []byte("....."), 0, []byte("a...."), true},
+		{'a', []byte("....."), 4, []byte("....a"), true},
+		{'a', []byte("....."), 5, []byte("....."), false},
+		{'Ł', []byte("....."), 0, []byte("{\\L}."), true},
+		{'Ł', []byte("....."), 4, []byte("....{"), false},
+	}
+
+	for i, d := range data {
+		ok := writeLaTeXRune(d.dst, d.r, &d.ndst)
+		if ok != d.expres {
+			t.Errorf("test %d: expected ok=%v, got ok=%v", i, d.expres, ok)
+		}
+		if !bytes.Equal(d.dst, d.expdst) {
+			t.Errorf("test %d: expected dst=%s, got dst=%s", i, d.expdst, d.dst)
+		}
+	}
+}
+
+func TestLaTeXLetter(t *testing.T) {
+	data := []struct {
+		letter  rune    // letter rune
+		accents []rune  // the accents of the letter
+		inGroup bool    // letter is in a group
+		opts    options // the output style
+		exp     string  // expected string
+	}{
+		{'a', nil, false, options{}, "a"},
+		{'a', nil, true, options{}, "{a}"},
+		{0, nil, true, options{}, ""},
+		{'§', nil, false, options{}, "{\\S}"},
+		{'ß', nil, false, options{}, "{\\ss}"},
+		{'ß', nil, false, options{terminator: EmptyTerminator}, "\\ss{}"},
+		{'ß', nil, false, options{terminator: SpaceTerminator}, "\\ss"},
+		{'ß', nil, false, options{terminator: EmptyTerminator, charGroups: true}, "{\\ss}"},
+		{'ı', []rune{0x301}, false, options{}, "\\i"},
+		{'ı', []rune{0x301}, true, options{}, "{\\i}"},
+		{'&', nil, false, options{escape: true}, "\\&"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: d.opts, letter: d.letter, accents: d.accents}
+		if got := lat.latexLetter(d.inGroup); got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestWriteLaTeXAccent(t *testing.T) {
+	data := []struct {
+		dst    []byte // destination slice
+		acc    []rune // accent rune
+		letter rune   // letter rune
+		exp    []byte // expected destination slice after writing
+		ndst   int    // number of bytes already written to the destination slice
+		expn   int    // expected number of bytes written to the destination slice after writing
+		expok  bool   // expected return value of the write operation
+	}{
+		{[]byte("....."), []rune{0x300}, 'a', []byte("\\`a.."), 0, 3, true},
+		{[]byte("....."), []rune{0x300}, 'a', []byte(".\\`a."), 1, 4, true},
+		{[]byte("....."), []rune{0x300}, 'a', []byte("..\\`a"), 2, 5, true},
+		{[]byte("....."), []rune{0x300}, 'a', []byte("...\\`"), 3, 3, false},
+		{[]byte("....."), []rune{0x300}, 'a', []byte("....\\"), 4, 4, false},
+		{[]byte("....."), []rune{0x300}, 'a', []byte("....."), 5, 5, false},
+		{[]byte("....."), []rune{0x327}, 'c', []byte("\\c{c}"), 0, 5, true},
+		{[]byte("....."), []rune{0x327}, 'c', []byte(".\\c{c"), 1, 1, false},
+		{[]byte("....."), []rune{0x327}, 'c', []byte("..\\c{"), 2, 2, false},
+		{[]byte("....."), []rune{0x327}, 'c', []byte("...\\c"), 3, 3, false},
+		{[]byte("....."), []rune{0x327}, 'c', []byte("....\\"), 4, 4, false},
+		{[]byte("....."), []rune{0x327}, 'c', []byte("....."), 5, 5, false},
+		{[]byte("......."), []rune{0x300, 0x301}, 'a', []byte("\\'\\`a.."), 0, 5, true},
+		{[]byte("......."), []rune{0x300, 0x301, 0x302}, 'a', []byte("\\^\\'\\`a"), 0, 7, true},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{accents: d.acc, letter: d.letter}
+		ok := lat.writeLaTeXAccent(d.dst, &d.ndst)
+		if ok != d.expok {
+			t.Errorf("test %d: expected ok=%v, got ok=%v", i, d.expok, ok)
+		}
+		if !bytes.Equal(d.dst, d.exp) {
+			t.Errorf("test %d: expected dst=%s, got dst=%s", i, d.exp, d.dst)
+		}
+		if d.ndst != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, d.ndst)
+		}
+	}
+}
+
+func TestToLaTeXAccents(t *testing.T) {
+	data := []struct {
+		src string // source string
+		exp string // expected destination string after writing
+	}{
+		{"a", "a"},
+		{"ç", "\\c{c}"},
+		{"é", "\\'e"},
+		{"bêtâ", "b\\^et\\^a"},
+		{"ḵ", "\\b{k}"},
+		{"Ceci est œuf", "Ceci est {\\oe}uf"},
+		{"Țară și", "\\textcommabelow{T}ar\\u{a} \\textcommabelow{s}i"},
+		{"ả ȁ ȃ", "\\h{a} \\G{a} \\f{a}"},
+		{"ḁ o\u030E o\u0361o", "\\textsubring{a} \\U{o} \\t{oo}"},
+		{"Þórr ð́ ŋ", "{\\TH}\\'orr \\'{\\dh} {\\ng}"},
+		{"Đurđa ĳs ẞ ħ ĸ", "{\\DJ}ur{\\dj}a {\\ij}s {\\SS} {\\texthbar} {\\textkra}"},
+		{"ī ĭ ǐ ĩ ǰ", "\\={\\i} \\u{\\i} \\v{\\i} \\~{\\i} \\v{\\j}"},
+		{"ị î̂ ḯ", "\\d{i} \\^\\^{\\i} \\'\\\"{\\i}"},
+		{"´ a¨b ¸x ˇ", "\\'{} a\\\"{}b \\c{}x \\v{}"},
+		{"\u0301x y\u0308", "\\'{}x \\\"y"},
+	}
+
+	var buf bytes.Buffer
+	for i, d := range data {
+		buf.Reset()
+		// a new writer for each test, as Close keeps the unprocessed bytes
+		w := transform.NewWriter(&buf, ToLaTeXAccents())
+		// convert d.src to NFD (required by ToLaTeXAccents)
+		src := norm.NFD.String(d.src)
+		_, err := w.Write([]byte(src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if buf.String() != d.exp {
+			t.Errorf("test %d: expected dst=%v, got dst=%v", i, d.exp, buf.String())
+		}
+	}
+}
+
+func TestToLaTeXAccents_MathAccents(t *testing.T) {
+	data := []struct {
+		mode texMode // the TeX mode
+		src  string  // source string
+		exp  string  // expected string
+	}{
+		{mathMode, "x̂", "\\hat{x}"},
+		{mathMode, "ā", "\\bar{a}"},
+		{mathMode, "v⃗", "\\vec{v}"},
+		{mathMode, "x̄̂", "\\hat{\\bar{x}}"},
+		{mathMode, "î", "\\hat{\\imath}"},
+		{mathMode, "ç", "\\c{c}"},
+		{mathMode, "x+y", "x+y"},
+		{textMode, "ā", "\\=a"},
+		{textMode, "v⃗", "v⃗"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{mode: d.mode}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_Symbols(t *testing.T) {
+	data := []struct {
+		mode       texMode // the TeX mode
+		ensureMath bool    // use \ensuremath
+		src        string  // source string
+		exp        string  // expected string
+	}{
+		{mathMode, false, "α+β", "\\alpha+\\beta"},
+		{mathMode, false, "αx", "\\alpha x"},
+		{mathMode, false, "α x", "\\alpha x"},
+		{mathMode, false, "x≤y", "x\\leq y"},
+		{mathMode, false, "a≠b", "a\\neq b"},
+		{mathMode, false, "a∉B", "a\\notin B"},
+		{mathMode, false, "f:A→B", "f:A\\to B"},
+		{mathMode, false, "α̂", "\\hat{\\alpha}"},
+		{textMode, false, "α", "$\\alpha$"},
+		{textMode, false, "αβ et γ", "$\\alpha\\beta$ et $\\gamma$"},
+		{textMode, false, "n→∞.", "n$\\to\\infty$."},
+		{textMode, true, "n→∞.", "n\\ensuremath{\\to\\infty}."},
+		{textMode, false, "é≤", "\\'e$\\leq$"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: options{symbols: true, ensureMath: d.ensureMath}, mode: d.mode}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_MathAlphabets(t *testing.T) {
+	data := []struct {
+		mode texMode // the TeX mode
+		src  string  // source string
+		exp  string  // expected string
+	}{
+		{mathMode, "ℝ^n", "\\mathbb{R}^n"},
+		{mathMode, "ℕℤℚ", "\\mathbb{NZQ}"},
+		{mathMode, "ℕ𝒪", "\\mathbb{N}\\mathcal{O}"},
+		{mathMode, "𝐱+𝐲", "\\mathbf{x}+\\mathbf{y}"},
+		{mathMode, "𝔤", "\\mathfrak{g}"},
+		{mathMode, "𝐱̂", "\\hat{\\mathbf{x}}"},
+		{textMode, "ℝ et ℂ", "$\\mathbb{R}$ et $\\mathbb{C}$"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: options{alphabets: true}, mode: d.mode}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_Scripts(t *testing.T) {
+	data := []struct {
+		mode texMode // the TeX mode
+		src  string  // source string
+		exp  string  // expected string
+	}{
+		{mathMode, "x²", "x^{2}"},
+		{mathMode, "x²³+aᵢ", "x^{23}+a_{i}"},
+		{mathMode, "x²ᵢ", "x^{2}_{i}"},
+		{mathMode, "eⁱ⁽ˣ⁺ʸ⁾", "e^{i(x+y)}"},
+		{textMode, "1ᵉʳ", "1\\textsuperscript{er}"},
+		{textMode, "H₂O", "H\\textsubscript{2}O"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: options{scripts: true}, mode: d.mode}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_TextSymbols(t *testing.T) {
+	data := []struct {
+		mode    texMode // the TeX mode
+		symbols bool    // convert the math symbols
+		src     string  // source string
+		exp     string  // expected string
+	}{
+		{textMode, false, "5€, 3£", "5{\\texteuro}, 3{\\pounds}"},
+		{textMode, false, "© 2024", "{\\copyright} 2024"},
+		{textMode, false, "§3 ¶2", "{\\S}3 {\\P}2"},
+		{textMode, false, "20°C", "20{\\textdegree}C"},
+		{textMode, false, "†‡", "{\\dag}{\\ddag}"},
+		{textMode, false, "½ ‰", "{\\textonehalf} {\\textperthousand}"},
+		{textMode, false, "¿Qué?", "{\\textquestiondown}Qu\\'e?"},
+		{textMode, true, "±2 × 3", "{\\textpm}2 {\\texttimes} 3"},
+		{mathMode, true, "±2 × 3", "\\pm2 \\times 3"},
+		{mathMode, false, "20°", "20°"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: options{symbols: d.symbols}, mode: d.mode}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_Quotes(t *testing.T) {
+	data := []struct {
+		quotes Quotes  // the quotes convention
+		mode   texMode // the TeX mode
+		src    string  // source string
+		exp    string  // expected string
+	}{
+		{EnglishQuotes, textMode, "“Hello” ‘x’ l’angle", "``Hello'' `x' l'angle"},
+		{GermanQuotes, textMode, "„Text“ ‚x‘ l’angle", "\\glqq Text\\grqq{} \\glq x\\grq{} l'angle"},
+		{FrenchQuotes, textMode, "« Salut »", "\\guillemotleft\\,Salut\\,\\guillemotright"},
+		{PolishQuotes, textMode, "„Tak”", ",,Tak''"},
+		{EnglishQuotes, mathMode, "f’", "f’"},
+		{NoQuotes, textMode, "“Hello”", "“Hello”"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: options{quotes: d.quotes}, mode: d.mode}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_Dashes(t *testing.T) {
+	data := []struct {
+		dashes bool    // convert the dashes
+		mode   texMode // the TeX mode
+		src    string  // source string
+		exp    string  // expected string
+	}{
+		{true, textMode, "1–2 a—b", "1--2 a---b"},
+		{true, textMode, "a… b…c", "a\\ldots{} b\\ldots c"},
+		{true, textMode, "x\u00a0y a\u202fb hy\u00adphen", "x~y a\\,b hy\\-phen"},
+		{true, mathMode, "a–b…", "a–b\\ldots"},
+		{false, textMode, "a–b…", "a–b…"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: options{dashes: d.dashes}, mode: d.mode}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_EscapeSpecials(t *testing.T) {
+	data := []struct {
+		escape bool   // escape the special characters
+		src    string // source string
+		exp    string // expected string
+	}{
+		{true, "R&D 50% _draft_ #1", "R\\&D 50\\% \\_draft\\_ \\#1"},
+		{true, "{x} $5", "\\{x\\} \\$5"},
+		{true, "a~b^c\\d", "a\\textasciitilde{}b\\textasciicircum{}c\\textbackslash{}d"},
+		{true, "é&è", "\\'e\\&\\`e"},
+		{false, "R&D 50% \\'e", "R&D 50% \\'e"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: options{escape: d.escape}}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_Styles(t *testing.T) {
+	data := []struct {
+		opts options // the output style
+		src  string  // source string
+		exp  string  // expected string
+	}{
+		{options{}, "é ç ǘ ß ßa í", "\\'e \\c{c} \\'\\\"u {\\ss} {\\ss}a \\'{\\i}"},
+		{options{accentArgs: BracedAccentArgs}, "é ç ǘ ḉ í", "\\'{e} \\c{c} \\'{\\\"{u}} \\'{\\c{c}} \\'{\\i}"},
+		{options{accentArgs: UnbracedAccentArgs}, "é ç ő ḉ í", "\\'e \\c c \\H o \\'\\c c \\'\\i"},
+		{options{accentArgs: UnbracedAccentArgs}, "ça ía", "\\c ca \\'\\i a"},
+		{options{charGroups: true}, "é ç ß", "{\\'e} {\\c{c}} {\\ss}"},
+		{options{terminator: EmptyTerminator}, "ß ßa §", "\\ss{} \\ss{}a \\S{}"},
+		{options{terminator: SpaceTerminator}, "ß ßa ß. ßé", "\\ss{} \\ss a \\ss. \\ss\\'e"},
+		{options{terminator: SpaceTerminator, charGroups: true}, "ß é", "{\\ss} {\\'e}"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{options: d.opts}
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_BibTeX(t *testing.T) {
+	data := []struct {
+		src string // source string
+		exp string // expected string
+	}{
+		{"Gödel", "G{\\\"{o}}del"},
+		{"Straße Ørsted", "Stra{\\ss}e {\\O}rsted"},
+		{"ế ǘ", "{\\'{\\^{e}}} {\\'{\\\"{u}}}"},
+		{"í Ångström", "{\\'{\\i}} {\\AA}ngstr{\\\"{o}}m"},
+		{"e\u0324 ơ", "e\u0324 o\u031B"},
+		{"ç\u0324", "{\\c{c\u0324}}"},
+	}
+
+	for i, d := range data {
+		lat := &toLaTeXAccents{}
+		WithBibTeX(true)(&lat.options)
+		got, _, err := transform.String(lat, norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+	// the diacritics without LaTeX accent are written with the fallback, outside a group
+	lat := &toLaTeXAccents{}
+	WithBibTeX(true)(&lat.options)
+	WithFallback(SymbolFallback)(&lat.options)
+	got, _, err := transform.String(lat, norm.NFD.String("ơ ç\u0324"))
+	exp := "o\\symbol{\"031B} {\\c{c\\symbol{\"0324}}}"
+	if err != nil || got != exp {
+		t.Errorf("expected %q, got %q (%v)", exp, got, err)
+	}
+}
+
+func TestToLaTeXAccents_DoubleAccents(t *testing.T) {
+	data := []struct {
+		src string // source string
+		exp string // expected string
+	}{
+		{"t\u0361s", "\\t{ts}"},
+		{"o\u0361o t\u035Cs", "\\t{oo} \\textbottomtiebar{ts}"},
+		{"t\u0361ʃa", "\\t{tʃ}a"},
+		{"ı\u0361u ø\u0361o", "\\t{\\i u} \\t{\\o o}"},
+		{"t\u0361s\u032A", "\\t{t}s\u032A"},
+		{"t\u0361", "\\t{t}"},
+		{"t\u0361 s", "\\t{t} s"},
+		{"o\u0360o", "o\u0360o"},
+	}
+
+	for i, d := range data {
+		got, _, err := transform.String(ToLaTeXAccents(), d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time (after the NFD segmentation) to check the chunk boundaries
+		tr := transform.Chain(norm.NFD, ToLaTeXAccents())
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), tr))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+func TestToLaTeXAccents_Fallback(t *testing.T) {
+	data := []struct {
+		fallback Fallback // the fallback style
+		src      string   // source string
+		exp      string   // expected string
+	}{
+		{KeepFallback, "ŋ ж ờ", "{\\ng} ж \\`o\u031B"},
+		{SymbolFallback, "ŋ ж ờ", "{\\ng} \\symbol{\"0436} \\`o\\symbol{\"031B}"},
+		{CharFallback, "ж1 й", "{\\char\"0436}1 \\u{\\char\"0438}"},
+		{HatFallback, "ж 𝒜", "^^^^0436 ^^^^^^01d49c"},
+		{UnicharFallback, "жé", "\\unichar{1078}\\'e"},
+		{SymbolFallback, "$ж$ \\verb|ж|", "$ж$ \\verb|ж|"},
+	}
+
+	for i, d := range data {
+		got, _, err := transform.String(ToLaTeXAccents(WithFallback(d.fallback)), norm.NFD.String(d.src))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToLaTeXAccents_ErrorFallback(t *testing.T) {
+	data := []struct {
+		quotes Quotes         // the quotes convention
+		src    string         // source string
+		exp    string         // expected string
+		chars  []UnmappedChar // expected unmapped characters
+	}{
+		{NoQuotes, "é", "\\'e", nil},
+		// nothing is written if some characters have no LaTeX form
+		{NoQuotes, "a\nжé\n\\begin{verbatim}\nж\n\\end{verbatim}\nŋ ơ\n", "", []UnmappedChar{{2, 1, 'ж'}, {6, 3, 0x31B}}},
+		{NoQuotes, "\nt\u0361ж", "", []UnmappedChar{{2, 3, 'ж'}}},
+		{FrenchQuotes, "«ж»\nж", "", []UnmappedChar{{1, 3, 'ж'}, {2, 1, 'ж'}}},
+		{NoQuotes, "é ж, ŋ and ю ж\n", "", []UnmappedChar{{1, 3, 'ж'}, {1, 12, 'ю'}, {1, 14, 'ж'}}},
+		{NoQuotes, "жж", "", []UnmappedChar{{1, 1, 'ж'}, {1, 2, 'ж'}}},
+		// the stray combining mark has its own column, not the one of its base
+		{NoQuotes, "ơ x\u031B", "", []UnmappedChar{{1, 1, 0x31B}, {1, 4, 0x31B}}},
+		{NoQuotes, strings.Repeat("é", 5000) + "\n", strings.Repeat("\\'e", 5000) + "\n", nil},
+	}
+
+	for i, d := range data {
+		tr := transform.Chain(norm.NFD, ToLaTeXAccents(WithFallback(ErrorFallback), WithQuotes(d.quotes)))
+		// feed the transformer one byte at a time to check the lines across the chunk boundaries
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), tr))
+		var u *UnmappedError
+		if d.chars == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if d.chars != nil && (!errors.As(err, &u) || !slices.Equal(u.Chars, d.chars)) {
+			t.Errorf("test %d: expected unmapped %v, got %v", i, d.chars, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
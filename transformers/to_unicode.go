@@ -1,312 +1,999 @@
-package transformers
-
-import (
-	"bytes"
-	"strings"
-
-	"golang.org/x/text/transform"
-)
-
-// toUnicodeAccents is a transformer that converts LaTeX accents to Unicode diacritics
-type toUnicodeAccents struct {
-	printBracket bool
-	letter       rune
-	accents      []rune
-}
-
-// ToLaTeXAccents returns a transformer that converts Unicode diacritics to LaTeX accents
-func ToUnicodeAccents() transform.Transformer {
-	return &toUnicodeAccents{}
-}
-
-// Reset resets the transformer
-func (t *toUnicodeAccents) Reset() {
-	t.printBracket = false
-	t.letter = 0
-	t.accents = t.accents[:0]
-}
-
-func (t *toUnicodeAccents) isZero() bool {
-	return !t.printBracket && t.letter == 0 && len(t.accents) == 0
-}
-
-// startGroup sets the startGroup flag
-func (t *toUnicodeAccents) startGroup(c byte) bool {
-	if c == '{' && t.isZero() {
-		t.printBracket = true
-		return true
-	}
-	return false
-}
-
-// write writes the utf8 letter ans accents to dst.
-func (t *toUnicodeAccents) write(dst []byte, nDst *int) (ok bool) {
-	n := *nDst
-	if t.printBracket {
-		if !writeByte(dst, '{', &n) {
-			return false
-		}
-	}
-	if t.letter != 0 {
-		if !writeRune(dst, t.letter, &n) {
-			return false
-		}
-	}
-	for i := len(t.accents) - 1; i >= 0; i-- {
-		if !writeRune(dst, t.accents[i], &n) {
-			return false
-		}
-	}
-	// everything was written, reset the transformer
-	*nDst = n
-	t.Reset()
-	return true
-}
-
-type latexSpecialType int
-
-const (
-	latexSpecialNone latexSpecialType = iota
-	latexSpecialLetter
-	latexSpecialNonLetterAccent
-	latexSpecialLetterAccent
-)
-
-type latexSpecial struct {
-	spType latexSpecialType
-	utf8   rune
-}
-
-var latexToUnicode = map[string]latexSpecial{
-	// Non-letter acccents
-	"`":  {latexSpecialNonLetterAccent, 0x300}, // grave : à
-	"'":  {latexSpecialNonLetterAccent, 0x301}, // acute : á
-	"^":  {latexSpecialNonLetterAccent, 0x302}, // circumflex : â
-	"~":  {latexSpecialNonLetterAccent, 0x303}, // tilde : ã
-	"=":  {latexSpecialNonLetterAccent, 0x304}, // macron : ā
-	".":  {latexSpecialNonLetterAccent, 0x307}, // dot-over : ġ
-	"\"": {latexSpecialNonLetterAccent, 0x308}, // two dots (umlaut, diaeresis) : ä
-	// Letter accents
-	"u": {latexSpecialLetterAccent, 0x306}, // breve : ă
-	"r": {latexSpecialLetterAccent, 0x30A}, // ring : å
-	"H": {latexSpecialLetterAccent, 0x30B}, // double acute (long Hungarian umlaut) : ő
-	"v": {latexSpecialLetterAccent, 0x30C}, // háček : č
-	"d": {latexSpecialLetterAccent, 0x323}, // dot-under : ẹ
-	"c": {latexSpecialLetterAccent, 0x327}, // cedilla : ç
-	"k": {latexSpecialLetterAccent, 0x328}, // ogonek : ą
-	"b": {latexSpecialLetterAccent, 0x331}, // bar-under (macron below) : ḵ
-	// Special letters
-	"L":  {latexSpecialLetter, 'Ł'},
-	"l":  {latexSpecialLetter, 'ł'},
-	"O":  {latexSpecialLetter, 'Ø'},
-	"o":  {latexSpecialLetter, 'ø'},
-	"i":  {latexSpecialLetter, 'ı'},
-	"j":  {latexSpecialLetter, 'ȷ'},
-	"AA": {latexSpecialLetter, 'Å'},
-	"aa": {latexSpecialLetter, 'å'},
-	"AE": {latexSpecialLetter, 'Æ'},
-	"ae": {latexSpecialLetter, 'æ'},
-	"OE": {latexSpecialLetter, 'Œ'},
-	"oe": {latexSpecialLetter, 'œ'},
-	"ss": {latexSpecialLetter, 'ß'},
-}
-
-var noneLatexSpecial = latexSpecial{spType: latexSpecialNone}
-
-const (
-	nonletteraccent string = "`'^~=.\""
-	firstOfTwo      string = "Aes" // no need to put Oo because they are letter accents
-)
-
-// getSpecial start looking for a latex special at the beggining of the src.
-// It returns true for needMore if src is empty or if the src is a single character
-// that is the beginning of a two character special.
-// In this case, it returns noneLatexSpecial and 0.
-// It also returns true for needMore if a special is found at the end of src
-// and it is not obvious it is not the beginning of some other macro.
-// But in this case, it returns the special and the number of bytes read,
-// because we can be at the end of the file.
-// If needMore is false, it returns the special and the number of bytes read.
-// If no special is found, it returns noneLatexSpecial and 0.
-// If the special is not a non-letter accent, it gobbles the next space if it is there.
-func getSpecial(src []byte) (ls latexSpecial, n int, needMore bool) {
-	if len(src) == 0 {
-		return noneLatexSpecial, 0, true
-	}
-	// if is a non-letter accent
-	if strings.IndexByte(nonletteraccent, src[0]) >= 0 {
-		return latexToUnicode[string(src[0])], 1, false
-	}
-	// get the longest possible latex macro name
-	i := 0
-	for ; i < len(src); i++ {
-		if !isLatin(src[i]) && src[i] != '@' {
-			break
-		}
-	}
-	if ls, ok := latexToUnicode[string(src[:i])]; ok {
-		if ls.spType == latexSpecialLetterAccent || ls.spType == latexSpecialLetter {
-			if i < len(src) && src[i] == ' ' {
-				// gobble the next space
-				return ls, i + 1, false
-			}
-			if ls.spType == latexSpecialLetter && i < len(src) && src[i] == '{' {
-				if i+1 == len(src) {
-					// maybe we should gobble the empty group
-					return ls, i, true
-				}
-				if src[i+1] == '}' {
-					// gobble the empty group
-					return ls, i + 2, false
-				}
-			}
-			return ls, i, i == len(src)
-		}
-		return ls, i, false
-	}
-	if len(src) == 1 {
-		// if the src is a single character that is the beginning of a two character special
-		// return needMore = true
-		return noneLatexSpecial, 0, strings.IndexByte(firstOfTwo, src[0]) >= 0
-	}
-	return noneLatexSpecial, 0, false
-}
-
-// getLetter check if the beginning of the src is a letter or {letter}.
-// If it is a letter or {letter}, it returns the letter and the number of bytes read (1 or 3).
-// If it is not a letter or {letter}, it returns 0,0, flase.
-// If the src is "{" or "{letter", it returns 0,0, true.
-func getLetter(src []byte) (l rune, n int, needMore bool) {
-	if len(src) == 0 {
-		return 0, 0, true
-	}
-	if src[0] == '{' {
-		if len(src) == 1 {
-			return 0, 0, true
-		}
-		if src[1] == '}' {
-			return 0, 2, false
-		}
-		if len(src) == 2 {
-			return 0, 0, true
-		}
-		if isLatin(src[1]) && src[2] == '}' {
-			return rune(src[1]), 3, false
-		}
-		if src[1] == '\\' {
-			ls, n, more := getSpecial(src[2:])
-			if more {
-				return 0, 0, true
-			}
-			if ls.spType != latexSpecialLetter || 2+n == len(src) || src[2+n] != '}' {
-				return 0, 0, false
-			}
-			return ls.utf8, 3 + n, false
-		}
-		return 0, 0, false
-	}
-	if !isLatin(src[0]) {
-		// we do not treat latexSpecialLetter here
-		return 0, 0, false
-	}
-	return rune(src[0]), 1, false
-}
-
-// Transform converts LaTeX accents to Unicode diacritics
-// src is supposed to be a valid UTF-8 string in NFD form
-func (t *toUnicodeAccents) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
-	for nSrc < len(src) {
-		if src[nSrc] != '\\' {
-			if t.startGroup(src[nSrc]) {
-				nSrc++
-				continue
-			}
-			if t.printBracket && src[nSrc] == '}' {
-				t.printBracket = false
-				nSrc++
-			}
-			// write collected accents to dst
-			if !t.write(dst, &nDst) {
-				// not enough space in dst
-				return nDst, nSrc, transform.ErrShortDst
-			}
-			// find the next \ in src
-			i := bytes.IndexByte(src[nSrc:], '\\')
-			if i < 0 {
-				i = len(src) - nSrc
-			}
-			if !write(dst, src[nSrc:nSrc+i], &nDst) {
-				// not enough space in dst
-				return nDst, nSrc, transform.ErrShortDst
-			}
-			nSrc += i
-			continue
-		}
-		// get the special
-		sp, n, needMore := getSpecial(src[nSrc+1:])
-		if needMore && !atEOF {
-			// we need more data to know how to process the special
-			return nDst, nSrc, transform.ErrShortSrc
-		}
-		if sp.spType == latexSpecialNone {
-			// n = 0 here
-			// write the accents (without letter) to dst
-			if !t.write(dst, &nDst) {
-				// not enough space in dst
-				return nDst, nSrc, transform.ErrShortDst
-			}
-			// can we get the escaped (after '\') character?
-			if nSrc+1 >= len(src) && !atEOF {
-				// we need more data to know how to process the special
-				return nDst, nSrc, transform.ErrShortSrc
-			}
-			// write the `\` to dst
-			if !writeByte(dst, '\\', &nDst) {
-				// not enough space in dst
-				return nDst, nSrc, transform.ErrShortDst
-			}
-			if !writeByte(dst, src[nSrc+1], &nDst) {
-				// not enough space in dst
-				return nDst, nSrc, transform.ErrShortDst
-			}
-			nSrc += 2
-			continue
-		}
-		n++
-		var m int
-		if sp.spType == latexSpecialLetter {
-			t.letter = sp.utf8
-		} else {
-			// get the letter
-			t.letter, m, needMore = getLetter(src[nSrc+n:])
-			if needMore && !atEOF {
-				// we need more data to know how to process the letter
-				return nDst, nSrc, transform.ErrShortSrc
-			}
-			t.accents = append(t.accents, sp.utf8)
-			n += m
-		}
-		nSrc += n
-		if t.printBracket {
-			if nSrc >= len(src) && !atEOF {
-				// we need more data to know how to process the letter
-				return nDst, nSrc, transform.ErrShortSrc
-			}
-			if src[nSrc] == '}' {
-				t.printBracket = false
-				nSrc++
-			}
-		}
-		if t.letter != 0 {
-			if !t.write(dst, &nDst) {
-				// not enough space in dst
-				return nDst, nSrc, transform.ErrShortDst
-			}
-		}
-	}
-	if !t.write(dst, &nDst) {
-		// not enough space in dst
-		return nDst, nSrc, transform.ErrShortDst
-	}
-	return nDst, nSrc, nil
-}
+package transformers
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// toUnicodeAccents is a transformer that converts LaTeX accents to Unicode diacritics
+type toUnicodeAccents struct {
+	options
+	printBracket bool
+	letter       rune
+	second       rune // the second letter of a double accent, like the s of \t{ts}
+	accents      []rune
+	open         int // the number of open groups of nested accents, like the { of \^{\'e}
+	mode         texMode
+	comment      bool     // the text to transform is a comment
+	definition   bool     // the text to transform is in the body of a macro definition
+	arg          macroArg // if a `{` here opens a macro argument, like after \emph or \href{...}
+	prev         byte     // the last byte of the previous src
+	lang         string   // the babel language selected, like ngerman
+	langs        []string // the languages to restore at the end of the otherlanguage environments
+}
+
+// ToUnicodeAccents returns a transformer that converts LaTeX accents to Unicode diacritics.
+// The content of the verbatim-like regions, and by default of the math, is passed through untouched.
+// The braces groups are removed according to WithBraces, and with WithPlain
+// the special characters are also unescaped and all the grouping braces removed.
+// With WithBibFields the input is a .bib file, only the selected fields are converted and the case is protected.
+// With WithShorthands the babel shorthands, like "a, are converted where their language is selected.
+func ToUnicodeAccents(opts ...Option) transform.Transformer {
+	o := newOptions(opts...)
+	if o.bibFields != nil {
+		// the braces of the uppercase letters in the values protect their case in BibTeX
+		o.protectCase = true
+	}
+	t := newTexRegions(&toUnicodeAccents{options: *o}, o)
+	if o.quotes == FrenchQuotes {
+		t = transform.Chain(t, newTexRegions(&frenchSpacing{}, o))
+	}
+	if o.plain || o.braces == StripRedundantBraces {
+		t = transform.Chain(t, newTexRegions(&braceGroups{plain: o.plain, protectCase: o.protectCase}, o))
+	}
+	if o.bibFields != nil {
+		t = newBibFields(t, o)
+	}
+	return t
+}
+
+// Reset resets the transformer
+func (t *toUnicodeAccents) Reset() {
+	t.clear()
+	t.prev = 0
+	t.arg = noMacroArg
+	t.lang = ""
+	t.langs = t.langs[:0]
+}
+
+// clear clears the collected letter and accents
+func (t *toUnicodeAccents) clear() {
+	t.printBracket = false
+	t.letter = 0
+	t.second = 0
+	t.accents = t.accents[:0]
+	t.open = 0
+}
+
+// setMode sets the TeX mode of the text to transform
+func (t *toUnicodeAccents) setMode(m texMode) {
+	t.mode = m
+}
+
+// setComment sets if the text to transform is a comment
+func (t *toUnicodeAccents) setComment(c bool) {
+	t.comment = c
+}
+
+// setDefinition sets if the text to transform is in the body of a macro definition
+func (t *toUnicodeAccents) setDefinition(d bool) {
+	t.definition = d
+}
+
+func (t *toUnicodeAccents) isZero() bool {
+	return !t.printBracket && t.letter == 0 && len(t.accents) == 0
+}
+
+// isZeroAccent returns true if no accent is collected, not even a nested one, like the \^ of \^{\'e}
+func (t *toUnicodeAccents) isZeroAccent() bool {
+	return len(t.accents) == 0 && t.open == 0
+}
+
+// startGroup holds the opening brace of a group that can be removed, like in {\'e}.
+// The braces of the macros arguments, like \emph{\'e}, are never removed.
+func (t *toUnicodeAccents) startGroup(c byte) bool {
+	if c != '{' || !t.isZero() || t.braces == KeepBraces || t.mode != textMode || t.arg.opensArg() {
+		return false
+	}
+	t.printBracket = true
+	return true
+}
+
+// isAccentGroup returns true if the open group contains only the converted letter, like {\'e},
+// and its braces can be removed
+func (t *toUnicodeAccents) isAccentGroup() bool {
+	return t.letter != 0 && !(t.protectCase && unicode.IsUpper(t.letter))
+}
+
+// before returns the byte before src[nSrc], possibly from the previous src
+func (t *toUnicodeAccents) before(src []byte, nSrc int) byte {
+	if nSrc > 0 {
+		return src[nSrc-1]
+	}
+	return t.prev
+}
+
+// dotted returns the letter with its dot if it is a dotless i or j under an accent above, like \'\i,
+// unless the dotless letters are kept
+func (t *toUnicodeAccents) dotted() rune {
+	if t.keepDotless {
+		return t.letter
+	}
+	for l, d := range dotlessLetters {
+		if t.letter == d && slices.ContainsFunc(t.accents, isAboveMark) {
+			return l
+		}
+	}
+	return t.letter
+}
+
+// write writes the utf8 letter ans accents to dst.
+func (t *toUnicodeAccents) write(dst []byte, nDst *int) (ok bool) {
+	n := *nDst
+	if t.printBracket {
+		if !writeByte(dst, '{', &n) {
+			return false
+		}
+	}
+	// the groups of the nested accents that are not closed are kept
+	if !write(dst, strings.Repeat("{", t.open), &n) {
+		return false
+	}
+	if t.letter != 0 {
+		if !writeRune(dst, t.dotted(), &n) {
+			return false
+		}
+	}
+	for i := len(t.accents) - 1; i >= 0; i-- {
+		if !writeRune(dst, t.accents[i], &n) {
+			return false
+		}
+	}
+	if t.second != 0 {
+		if !writeRune(dst, t.second, &n) {
+			return false
+		}
+	}
+	// everything was written, clear the transformer
+	*nDst = n
+	t.clear()
+	return true
+}
+
+type latexSpecialType int
+
+const (
+	latexSpecialNone latexSpecialType = iota
+	latexSpecialLetter
+	latexSpecialNonLetterAccent
+	latexSpecialLetterAccent
+	latexSpecialMathAccent
+	latexSpecialSymbol
+	latexSpecialAlphabet
+	latexSpecialScript
+	latexSpecialQuote
+	latexSpecialControl
+	latexSpecialDash
+	latexSpecialCharCode   // a character given by its code, like \char"E9
+	latexSpecialAccentCode // an accent given by its slot in the font, like \accent"7F
+)
+
+type latexSpecial struct {
+	spType latexSpecialType
+	utf8   rune
+}
+
+var latexToUnicode = map[string]latexSpecial{
+	// Non-letter acccents
+	"`":  {latexSpecialNonLetterAccent, 0x300}, // grave : à
+	"'":  {latexSpecialNonLetterAccent, 0x301}, // acute : á
+	"^":  {latexSpecialNonLetterAccent, 0x302}, // circumflex : â
+	"~":  {latexSpecialNonLetterAccent, 0x303}, // tilde : ã
+	"=":  {latexSpecialNonLetterAccent, 0x304}, // macron : ā
+	".":  {latexSpecialNonLetterAccent, 0x307}, // dot-over : ġ
+	"\"": {latexSpecialNonLetterAccent, 0x308}, // two dots (umlaut, diaeresis) : ä
+	// Letter accents
+	"u": {latexSpecialLetterAccent, 0x306}, // breve : ă
+	"r": {latexSpecialLetterAccent, 0x30A}, // ring : å
+	"H": {latexSpecialLetterAccent, 0x30B}, // double acute (long Hungarian umlaut) : ő
+	"v": {latexSpecialLetterAccent, 0x30C}, // háček : č
+	"d": {latexSpecialLetterAccent, 0x323}, // dot-under : ẹ
+	"c": {latexSpecialLetterAccent, 0x327}, // cedilla : ç
+	"k": {latexSpecialLetterAccent, 0x328}, // ogonek : ą
+	"b": {latexSpecialLetterAccent, 0x331}, // bar-under (macron below) : ḵ
+	"h": {latexSpecialLetterAccent, 0x309}, // hook above (vntex) : ả
+	"U": {latexSpecialLetterAccent, 0x30E}, // double vertical line above : a̎
+	"G": {latexSpecialLetterAccent, 0x30F}, // double grave : ȁ
+	"f": {latexSpecialLetterAccent, 0x311}, // inverted breve : ȃ
+	"t": {latexSpecialLetterAccent, 0x361}, // tie (double inverted breve) : t͡s
+	// Named accents
+	"textsubring":        {latexSpecialLetterAccent, 0x325}, // ring below : ḁ
+	"textcommabelow":     {latexSpecialLetterAccent, 0x326}, // comma below : ș
+	"textogonekcentered": {latexSpecialLetterAccent, 0x328}, // centered ogonek : ǫ
+	"textsubdot":         {latexSpecialLetterAccent, 0x323}, // dot-under (tipa) : ẹ
+	"texttoptiebar":      {latexSpecialLetterAccent, 0x361}, // tie (tipa) : t͡s
+	"textbottomtiebar":   {latexSpecialLetterAccent, 0x35C}, // tie below (tipa) : t͜s
+	// Special letters
+	"L":  {latexSpecialLetter, 'Ł'},
+	"l":  {latexSpecialLetter, 'ł'},
+	"O":  {latexSpecialLetter, 'Ø'},
+	"o":  {latexSpecialLetter, 'ø'},
+	"i":  {latexSpecialLetter, 'ı'},
+	"j":  {latexSpecialLetter, 'ȷ'},
+	"AA": {latexSpecialLetter, 'Å'},
+	"aa": {latexSpecialLetter, 'å'},
+	"AE": {latexSpecialLetter, 'Æ'},
+	"ae": {latexSpecialLetter, 'æ'},
+	"OE": {latexSpecialLetter, 'Œ'},
+	"oe": {latexSpecialLetter, 'œ'},
+	"ss": {latexSpecialLetter, 'ß'},
+	"SS": {latexSpecialLetter, 'ẞ'},
+	"DH": {latexSpecialLetter, 'Ð'},
+	"dh": {latexSpecialLetter, 'ð'},
+	"TH": {latexSpecialLetter, 'Þ'},
+	"th": {latexSpecialLetter, 'þ'},
+	"DJ": {latexSpecialLetter, 'Đ'},
+	"dj": {latexSpecialLetter, 'đ'},
+	"NG": {latexSpecialLetter, 'Ŋ'},
+	"ng": {latexSpecialLetter, 'ŋ'},
+	"IJ": {latexSpecialLetter, 'Ĳ'},
+	"ij": {latexSpecialLetter, 'ĳ'},
+	// Special letters without T1 glyph
+	"textHbar": {latexSpecialLetter, 'Ħ'},
+	"texthbar": {latexSpecialLetter, 'ħ'},
+	"textcrh":  {latexSpecialLetter, 'ħ'}, // tipa
+	"Ldot":     {latexSpecialLetter, 'Ŀ'},
+	"ldot":     {latexSpecialLetter, 'ŀ'},
+	"textkra":  {latexSpecialLetter, 'ĸ'},
+}
+
+// accentSpacings are the spacing forms of the diacritics, like ´ for \'{}
+var accentSpacings = map[rune]rune{}
+
+func init() {
+	for spacing, a := range spacingAccents {
+		accentSpacings[a] = spacing
+	}
+}
+
+// latexMathAccents are the math accents, converted only in math mode
+var latexMathAccents = map[string]latexSpecial{
+	"grave":    {latexSpecialMathAccent, 0x300},  // grave : \grave{a} = à
+	"acute":    {latexSpecialMathAccent, 0x301},  // acute : \acute{a} = á
+	"hat":      {latexSpecialMathAccent, 0x302},  // circumflex : \hat{x} = x̂
+	"tilde":    {latexSpecialMathAccent, 0x303},  // tilde : \tilde{n} = ñ
+	"bar":      {latexSpecialMathAccent, 0x304},  // macron : \bar{a} = ā
+	"breve":    {latexSpecialMathAccent, 0x306},  // breve : \breve{a} = ă
+	"dot":      {latexSpecialMathAccent, 0x307},  // dot-over : \dot{x} = ẋ
+	"ddot":     {latexSpecialMathAccent, 0x308},  // two dots : \ddot{x} = ẍ
+	"mathring": {latexSpecialMathAccent, 0x30A},  // ring : \mathring{a} = å
+	"check":    {latexSpecialMathAccent, 0x30C},  // háček : \check{c} = č
+	"vec":      {latexSpecialMathAccent, 0x20D7}, // right arrow above : \vec{v} = v⃗
+	"dddot":    {latexSpecialMathAccent, 0x20DB}, // three dots : \dddot{x} = x⃛
+	"ddddot":   {latexSpecialMathAccent, 0x20DC}, // four dots : \ddddot{x} = x⃜
+}
+
+// latexSpecialTables are the tables in which the LaTeX specials are looked for
+var latexSpecialTables = []map[string]latexSpecial{
+	latexToUnicode,
+	latexTextSymbols,
+	latexMathAccents,
+	latexSymbols,
+	latexMathAlphabets,
+	latexScripts,
+	latexQuotes,
+	latexDashes,
+	latexCharCodes,
+}
+
+// specialPrefixes contains all strict prefixes of the specials names
+var specialPrefixes = map[string]bool{}
+
+func init() {
+	for _, table := range latexSpecialTables {
+		for name := range table {
+			for i := 1; i < len(name); i++ {
+				specialPrefixes[name[:i]] = true
+			}
+		}
+	}
+}
+
+// lookupSpecial returns the special with the given name
+func lookupSpecial(name string) (latexSpecial, bool) {
+	for _, table := range latexSpecialTables {
+		if ls, ok := table[name]; ok {
+			return ls, true
+		}
+	}
+	return noneLatexSpecial, false
+}
+
+// isCharacter returns true if the special is converted to a single character (like \ss), not to an accent
+func (ls latexSpecial) isCharacter() bool {
+	switch ls.spType {
+	case latexSpecialLetter, latexSpecialSymbol, latexSpecialQuote, latexSpecialControl, latexSpecialDash:
+		return true
+	}
+	return false
+}
+
+var noneLatexSpecial = latexSpecial{spType: latexSpecialNone}
+
+const nonletteraccent string = "`'^~=.\""
+
+// getSpecial start looking for a latex special at the beggining of the src.
+// It returns true for needMore if src is empty or if the src is
+// the beginning of a longer special name.
+// In this case, it returns noneLatexSpecial and 0.
+// It also returns true for needMore if a special is found at the end of src
+// and it is not obvious it is not the beginning of some other macro.
+// But in this case, it returns the special and the number of bytes read,
+// because we can be at the end of the file.
+// If needMore is false, it returns the special and the number of bytes read.
+// If no special is found, it returns noneLatexSpecial and 0.
+// If the special is not a non-letter accent, it gobbles the next space if it is there.
+func getSpecial(src []byte) (ls latexSpecial, n int, needMore bool) {
+	if len(src) == 0 {
+		return noneLatexSpecial, 0, true
+	}
+	// if is a non-letter accent
+	if strings.IndexByte(nonletteraccent, src[0]) >= 0 {
+		return latexToUnicode[string(src[0])], 1, false
+	}
+	// if is a spacing (or hyphenation) control symbol
+	if ls, ok := latexControlSymbols[string(src[0])]; ok {
+		return ls, 1, false
+	}
+	// get the longest possible latex macro name
+	i := 0
+	for ; i < len(src); i++ {
+		if !isLatin(src[i]) && src[i] != '@' {
+			break
+		}
+	}
+	if ls, ok := lookupSpecial(string(src[:i])); ok {
+		if ls.spType != latexSpecialNonLetterAccent {
+			if i < len(src) && src[i] == ' ' {
+				// gobble the next space
+				return ls, i + 1, false
+			}
+			if ls.isCharacter() && i < len(src) && src[i] == '{' {
+				if i+1 == len(src) {
+					// maybe we should gobble the empty group
+					return ls, i, true
+				}
+				if src[i+1] == '}' {
+					// gobble the empty group
+					return ls, i + 2, false
+				}
+			}
+			return ls, i, i == len(src)
+		}
+		return ls, i, false
+	}
+	// if the src is the beginning of a longer special return needMore = true
+	return noneLatexSpecial, 0, i == len(src) && specialPrefixes[string(src)]
+}
+
+// isConverted returns true if the special should be converted with the current options
+func (t *toUnicodeAccents) isConverted(ls latexSpecial) bool {
+	switch ls.spType {
+	case latexSpecialNone:
+		return false
+	case latexSpecialSymbol:
+		return t.symbols
+	case latexSpecialAlphabet:
+		return t.alphabets && t.mode == mathMode
+	case latexSpecialScript:
+		return t.scripts
+	case latexSpecialQuote:
+		return t.quotes != NoQuotes
+	case latexSpecialControl:
+		return t.dashes || (t.quotes == FrenchQuotes && ls.utf8 == 0x202F)
+	case latexSpecialDash:
+		return t.dashes
+	case latexSpecialCharCode, latexSpecialAccentCode:
+		return t.decodeCodes()
+	}
+	return true
+}
+
+// argConverter returns the function that converts the characters of the argument of the special
+func argConverter(ls latexSpecial) func(rune) (rune, bool) {
+	if ls.spType == latexSpecialScript {
+		return scriptConverter(ls.utf8)
+	}
+	return mathAlphabetsByUpper[ls.utf8].letter
+}
+
+// maxArg is the maximal length of an argument we convert at once, like in \mathbb{NZ}
+const maxArg = 64
+
+// getConvertedArg looks for an argument at the beginning of src, like {NZ} or N,
+// and converts each of its ASCII characters with conv.
+// It returns the converted argument and the number of bytes read.
+// If some character can not be converted, it returns an empty string.
+// It returns needMore if src is too short to decide.
+func getConvertedArg(src []byte, conv func(rune) (rune, bool)) (s string, n int, needMore bool) {
+	if len(src) == 0 {
+		return "", 0, true
+	}
+	arg := src[:1]
+	n = 1
+	if src[0] == '{' {
+		i := 1
+		for i < len(src) && i <= maxArg && src[i] != '}' {
+			i++
+		}
+		if i == len(src) {
+			return "", 0, i <= maxArg
+		}
+		if src[i] != '}' || i == 1 {
+			return "", 0, false
+		}
+		arg = src[1:i]
+		n = i + 1
+	}
+	runes := make([]rune, 0, len(arg))
+	for _, c := range arg {
+		r, ok := conv(rune(c))
+		if !ok {
+			return "", 0, false
+		}
+		runes = append(runes, r)
+	}
+	return string(runes), n, false
+}
+
+// isScript returns true if c starts a super/subscript that should be converted, like ^{2}
+func (t *toUnicodeAccents) isScript(c byte) bool {
+	return t.scripts && t.mode == mathMode && (c == '^' || c == '_')
+}
+
+// isQuote returns true if c can start a quote ligature that should be converted, like “
+func (t *toUnicodeAccents) isQuote(c byte) bool {
+	return t.quotes != NoQuotes && t.mode == textMode && strings.IndexByte(quoteLigatures, c) >= 0
+}
+
+// isShorthandQuote returns true if src[nSrc] ends a German quote of babel, like "` or "', that is not a ligature
+func (t *toUnicodeAccents) isShorthandQuote(src []byte, nSrc int) bool {
+	return t.quotes == GermanQuotes && t.before(src, nSrc) == '"' && (src[nSrc] == '`' || src[nSrc] == '\'')
+}
+
+// isDash returns true if c can start a dash ligature (or a ~) that should be converted
+func (t *toUnicodeAccents) isDash(c byte) bool {
+	return t.dashes && t.mode == textMode && !t.comment && (c == '-' || c == '~')
+}
+
+// nextSpecial returns the position of the next byte in src that can start a conversion
+func (t *toUnicodeAccents) nextSpecial(src []byte) int {
+	specials := "\\"
+	if t.scripts && t.mode == mathMode {
+		specials += "^_"
+	}
+	if t.quotes != NoQuotes && t.mode == textMode {
+		specials += quoteLigatures
+	}
+	if t.dashes && t.mode == textMode && !t.comment {
+		specials += "-~"
+	}
+	if t.braces != KeepBraces && t.mode == textMode {
+		specials += "{"
+	}
+	if t.decodeCodes() {
+		specials += "^"
+	}
+	if t.shorthandTable() != nil {
+		specials += "\""
+	}
+	return bytes.IndexAny(src, specials)
+}
+
+// isBase returns true if r can be the base of an accent, like e, 1 or ø, but not a mark or a space
+func isBase(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isLatin(r) || ('0' <= r && r <= '9')
+	}
+	return unicode.IsGraphic(r) && !unicode.IsSpace(r) && !unicode.Is(unicode.M, r)
+}
+
+// getBase checks if the beginning of src is an accent base (a letter, a digit or a Unicode character)
+// and returns it with its size. It returns needMore if src is too short to know the character.
+func getBase(src []byte) (l rune, n int, needMore bool) {
+	if !utf8.FullRune(src) {
+		return 0, 0, true
+	}
+	r, size := utf8.DecodeRune(src)
+	if r == utf8.RuneError || !isBase(r) {
+		return 0, 0, false
+	}
+	return r, size, false
+}
+
+// getLetter checks if the beginning of the src is an accent base: a letter, a digit or a Unicode character,
+// possibly braced, like e, {e}, 1 or {ø}, or a braced letter macro, like {\ae}.
+// If it is the case, it returns the letter and the number of bytes read.
+// If it is an empty group {}, it returns 0 and 2.
+// If it is not an accent base, it returns 0, 0, false.
+// If the src is the beginning of an accent base, like "{" or "{e", it returns 0, 0, true.
+func getLetter(src []byte) (l rune, n int, needMore bool) {
+	if len(src) == 0 {
+		return 0, 0, true
+	}
+	if src[0] != '{' {
+		// we do not treat latexSpecialLetter here
+		return getBase(src)
+	}
+	if len(src) == 1 {
+		return 0, 0, true
+	}
+	if src[1] == '}' {
+		return 0, 2, false
+	}
+	if src[1] == '\\' {
+		ls, n, more := getSpecial(src[2:])
+		if more {
+			return 0, 0, true
+		}
+		if ls.spType != latexSpecialLetter || 2+n == len(src) || src[2+n] != '}' {
+			return 0, 0, false
+		}
+		return ls.utf8, 3 + n, false
+	}
+	l, n, needMore = getBase(src[1:])
+	if l == 0 {
+		return 0, 0, needMore
+	}
+	if 1+n == len(src) {
+		return 0, 0, true
+	}
+	if src[1+n] != '}' {
+		return 0, 0, false
+	}
+	return l, 2 + n, false
+}
+
+// isNestedAccent returns true if the beginning of src is a group starting with an accent, like {\'e}.
+// It returns needMore if src is too short to know.
+func isNestedAccent(src []byte) (nested, needMore bool) {
+	switch {
+	case len(src) == 0 || (len(src) == 1 && src[0] == '{'):
+		return false, true
+	case len(src) == 1 || src[0] != '{' || src[1] != '\\':
+		return false, false
+	}
+	ls, _, needMore := getSpecial(src[2:])
+	switch ls.spType {
+	case latexSpecialNonLetterAccent, latexSpecialLetterAccent:
+		return true, false
+	}
+	return false, needMore
+}
+
+// isDoubleAccent returns true if the diacritic spans two letters, like the tie of t͡s
+func isDoubleAccent(r rune) bool {
+	return 0x35C <= r && r <= 0x362
+}
+
+// getPairLetter checks if the beginning of src is a letter of a double accent argument:
+// an accent base, like t or ʃ, or a letter macro, like \i or \o, with its gobbled space.
+// It returns the letter and the number of bytes read, or 0 if it is not a letter.
+// It returns needMore if src is too short to decide.
+func getPairLetter(src []byte) (l rune, n int, needMore bool) {
+	if len(src) == 0 {
+		return 0, 0, true
+	}
+	if src[0] != '\\' {
+		return getBase(src)
+	}
+	ls, n, needMore := getSpecial(src[1:])
+	if needMore || ls.spType != latexSpecialLetter {
+		return 0, 0, needMore
+	}
+	return ls.utf8, 1 + n, false
+}
+
+// getLetterPair checks if the beginning of src is a group of two letters, like {ts}, {dʒ} or {\i u},
+// the argument of a double accent.
+// It returns the letters and the number of bytes read, or 0 if it is not a pair.
+// It returns needMore if src is too short to decide.
+func getLetterPair(src []byte) (l1, l2 rune, n int, needMore bool) {
+	if len(src) == 0 {
+		return 0, 0, 0, true
+	}
+	if src[0] != '{' {
+		return 0, 0, 0, false
+	}
+	n = 1
+	l1, m, needMore := getPairLetter(src[n:])
+	if l1 == 0 {
+		return 0, 0, 0, needMore
+	}
+	n += m
+	l2, m, needMore = getPairLetter(src[n:])
+	if l2 == 0 {
+		return 0, 0, 0, needMore
+	}
+	n += m
+	if n == len(src) {
+		return 0, 0, 0, true
+	}
+	if src[n] != '}' {
+		return 0, 0, 0, false
+	}
+	return l1, l2, n + 1, false
+}
+
+// Transform converts LaTeX accents to Unicode diacritics
+// src is supposed to be a valid UTF-8 string in NFD form
+func (t *toUnicodeAccents) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	// remember the last processed byte, to look behind at the beginning of the next src
+	defer func() {
+		if nSrc > 0 {
+			t.prev = src[nSrc-1]
+		}
+	}()
+	for nSrc < len(src) {
+		if src[nSrc] != '\\' {
+			if t.open > 0 && t.letter != 0 {
+				// close the groups of the nested accents, like \^{\'e}
+				for t.open > 0 && nSrc < len(src) && src[nSrc] == '}' {
+					t.open--
+					nSrc++
+				}
+				if nSrc == len(src) || src[nSrc] == '\\' {
+					continue
+				}
+			}
+			if t.printBracket && src[nSrc] == '}' && t.isAccentGroup() {
+				// the group contains only the converted letter, like {\'e}
+				t.printBracket = false
+				nSrc++
+			}
+			// write collected accents to dst
+			if !t.write(dst, &nDst) {
+				// not enough space in dst
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			if nSrc < len(src) && t.startGroup(src[nSrc]) {
+				nSrc++
+				continue
+			}
+			if nSrc < len(src) && t.arg.next(src[nSrc]) == noMacroArg {
+				// the byte can be converted here, but it ends the macro anyway
+				t.arg = noMacroArg
+			}
+			if nSrc < len(src) && src[nSrc] == '^' && t.decodeCodes() {
+				// decode the ^^ notation, like ^^e9
+				r, m, needMore := getHatCode(src[nSrc:])
+				if needMore && !atEOF {
+					// we need more data to know the code
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				if m > 0 && isCode(r) {
+					if !writeRune(dst, r, &nDst) {
+						// not enough space in dst
+						return nDst, nSrc, transform.ErrShortDst
+					}
+					nSrc += m
+					continue
+				}
+			}
+			if nSrc < len(src) && t.isScript(src[nSrc]) {
+				// convert the super/subscript, like ^{2}
+				s, m, needMore := getConvertedArg(src[nSrc+1:], scriptConverter(rune(src[nSrc])))
+				if needMore && !atEOF {
+					// we need more data to know how to process the script
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				if s == "" {
+					s, m = string(src[nSrc]), 0
+				}
+				if !write(dst, s, &nDst) {
+					// not enough space in dst
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				nSrc += 1 + m
+				continue
+			}
+			if nSrc < len(src) && t.isDash(src[nSrc]) {
+				// convert the dash ligature, like --, or the ~
+				d, m, needMore := getDash(src[nSrc:])
+				if needMore && !atEOF {
+					// we need more data to know how to process the dash
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				if src[nSrc] == '~' {
+					d, m = 0xA0, 1
+				}
+				if m > 0 && nSrc+m == len(src) && !atEOF {
+					// we need the next byte to know if it is an option value
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				if m == 0 || (d != 0xA0 && nSrc+m < len(src) && isOptionValue(t.before(src, nSrc), src[nSrc+m])) {
+					d, m = rune(src[nSrc]), 1
+					for nSrc+m < len(src) && src[nSrc+m] == '-' {
+						m++
+					}
+					if !write(dst, src[nSrc:nSrc+m], &nDst) {
+						// not enough space in dst
+						return nDst, nSrc, transform.ErrShortDst
+					}
+					nSrc += m
+					continue
+				}
+				if !writeRune(dst, d, &nDst) {
+					// not enough space in dst
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				nSrc += m
+				continue
+			}
+			if table := t.shorthandTable(); nSrc < len(src) && src[nSrc] == '"' && table != nil {
+				// convert the babel shorthand, like "a
+				s, m, needMore := getShorthand(table, src[nSrc:])
+				if needMore && !atEOF {
+					// we need more data to know the shorthand
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				if m == 0 {
+					s, m = "\"", 1
+				}
+				if !write(dst, s, &nDst) {
+					// not enough space in dst
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				nSrc += m
+				continue
+			}
+			if nSrc < len(src) && t.isQuote(src[nSrc]) && !t.isShorthandQuote(src, nSrc) {
+				// convert the quote ligature, like ``
+				q, m, needMore := getQuote(src[nSrc:])
+				if needMore && !atEOF {
+					// we need more data to know how to process the quote
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				if m == 0 {
+					q, m = rune(src[nSrc]), 1
+				}
+				if !writeRune(dst, q, &nDst) {
+					// not enough space in dst
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				nSrc += m
+				continue
+			}
+			// find the next special in src, the current byte is not converted (like the `{` of an argument)
+			i := len(src) - nSrc
+			if i > 0 {
+				if j := t.nextSpecial(src[nSrc+1:]); j >= 0 {
+					i = 1 + j
+				}
+			}
+			if !write(dst, src[nSrc:nSrc+i], &nDst) {
+				// not enough space in dst
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			// the letters after an unconverted macro, like \emph, are part of its name,
+			// and the optional (or other) arguments can be followed by an argument, like \cite[p]{...}
+			t.arg = t.arg.nextBytes(src[nSrc : nSrc+i])
+			nSrc += i
+			continue
+		}
+		if t.letter != 0 {
+			// the letter of nested accents without closing brace
+			if !t.write(dst, &nDst) {
+				// not enough space in dst
+				return nDst, nSrc, transform.ErrShortDst
+			}
+		}
+		if t.shorthands != nil && !t.comment {
+			// follow the language changes, like \selectlanguage{ngerman}
+			sw, lang, m, needMore := getLanguageSwitch(src[nSrc:])
+			if needMore && !atEOF {
+				// we need more data to know the command
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if sw != noSwitch {
+				if !t.write(dst, &nDst) || !write(dst, src[nSrc:nSrc+m], &nDst) {
+					// not enough space in dst
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				t.switchLanguage(sw, lang)
+				t.arg = noMacroArg.nextBytes(src[nSrc : nSrc+m])
+				nSrc += m
+				continue
+			}
+		}
+		// get the special
+		sp, n, needMore := getSpecial(src[nSrc+1:])
+		if needMore && !atEOF {
+			// we need more data to know how to process the special
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		if sp.spType == latexSpecialMathAccent {
+			// math accents are converted only in math mode and if followed by a letter or an other accent
+			next := src[nSrc+1+n:]
+			l, _, needMore := getLetter(next)
+			if l == 0 && len(next) > 0 && next[0] == '\\' {
+				var nsp latexSpecial
+				nsp, _, needMore = getSpecial(next[1:])
+				if t.isConverted(nsp) {
+					l = -1
+				}
+			}
+			if needMore && !atEOF {
+				// we need more data to know how to process the letter
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if t.mode != mathMode || l == 0 {
+				sp = noneLatexSpecial
+			}
+		}
+		if !t.isConverted(sp) {
+			sp = noneLatexSpecial
+		}
+		if sp.spType == latexSpecialAlphabet || sp.spType == latexSpecialScript {
+			// the math alphabets and the scripts are converted with their argument, like \mathbb{R}
+			s, m, needMore := getConvertedArg(src[nSrc+1+n:], argConverter(sp))
+			if needMore && !atEOF {
+				// we need more data to know how to process the argument
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if s == "" {
+				sp = noneLatexSpecial
+			} else {
+				if !t.write(dst, &nDst) || !write(dst, s, &nDst) {
+					// not enough space in dst
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				nSrc += 1 + n + m
+				continue
+			}
+		}
+		if sp.spType == latexSpecialCharCode || sp.spType == latexSpecialAccentCode {
+			// the character (or the accent) is given by its code, like \char"E9 or \accent"7F e
+			v, m, needMore := getCharCode(src[nSrc+1+n:])
+			if needMore && !atEOF {
+				// we need more data to know the code
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			a, isSlot := accentSlots[v]
+			switch {
+			case m == 0:
+				sp = noneLatexSpecial
+			case sp.spType == latexSpecialAccentCode && isSlot:
+				sp = latexSpecial{latexSpecialLetterAccent, a}
+				n += m
+			case sp.spType == latexSpecialCharCode && isCode(v):
+				sp = latexSpecial{latexSpecialLetter, v}
+				n += m
+			default:
+				sp = noneLatexSpecial
+			}
+		}
+		if sp.spType == latexSpecialNone {
+			// n = 0 here
+			// write the accents (without letter) to dst
+			if !t.write(dst, &nDst) {
+				// not enough space in dst
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			// can we get the escaped (after '\') character?
+			if nSrc+1 >= len(src) && !atEOF {
+				// we need more data to know how to process the special
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			// write the `\` to dst
+			if !writeByte(dst, '\\', &nDst) {
+				// not enough space in dst
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			if nSrc+1 == len(src) {
+				// the `\` is the last character
+				nSrc++
+				continue
+			}
+			if !writeByte(dst, src[nSrc+1], &nDst) {
+				// not enough space in dst
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			t.arg = afterEscape.next(src[nSrc+1])
+			nSrc += 2
+			continue
+		}
+		n++
+		var m int
+		if sp.isCharacter() {
+			t.letter = sp.utf8
+		} else {
+			if isDoubleAccent(sp.utf8) {
+				// the double accents span two letters, like \t{ts}
+				t.letter, t.second, m, needMore = getLetterPair(src[nSrc+n:])
+			}
+			if m == 0 && (!needMore || atEOF) {
+				// get the letter
+				t.letter, m, needMore = getLetter(src[nSrc+n:])
+			}
+			if m == 0 && !needMore && t.decodeCodes() {
+				// the letter can be given by its code, like \u{\char"0438}
+				t.letter, m, needMore = getCodeLetter(src[nSrc+n:])
+			}
+			nested := false
+			if m == 0 && !needMore {
+				// the argument can be an other accent, like \^{\'e}
+				nested, needMore = isNestedAccent(src[nSrc+n:])
+			}
+			if needMore && !atEOF {
+				// we need more data to know how to process the letter
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			spacing, hasSpacing := accentSpacings[sp.utf8]
+			empty := m == 2 && t.letter == 0
+			switch {
+			case empty && t.isZeroAccent() && hasSpacing:
+				// the accent with an empty argument is the spacing accent, like \'{} to ´
+				t.letter = spacing
+			case empty && t.isZeroAccent():
+				// the accent without spacing form, like \d{}, is kept and not written as a lone combining mark
+				if !t.write(dst, &nDst) || !write(dst, src[nSrc:nSrc+n+m], &nDst) {
+					// not enough space in dst
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				nSrc += n + m
+				t.arg = noMacroArg
+				continue
+			case empty:
+				// the accents already read, like in \'\d{} or \'{\'{}}, are put on a no-break space
+				t.accents = append(t.accents, sp.utf8)
+				t.letter = '\u00A0'
+			default:
+				t.accents = append(t.accents, sp.utf8)
+			}
+			if nested {
+				// the opening brace is gobbled, the closing one is after the letter
+				t.open++
+				m = 1
+			}
+			n += m
+		}
+		nSrc += n
+		t.arg = noMacroArg
+		if t.open > 0 && t.letter != 0 {
+			// the closing braces of the nested accents are gobbled before the letter is written
+			continue
+		}
+		if t.printBracket {
+			if nSrc >= len(src) && !atEOF {
+				// we need more data to know how to process the letter
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if nSrc < len(src) && src[nSrc] == '}' && t.isAccentGroup() {
+				// the group contains only the converted letter, like {\'e}
+				t.printBracket = false
+				nSrc++
+			}
+		}
+		if t.letter != 0 {
+			if !t.write(dst, &nDst) {
+				// not enough space in dst
+				return nDst, nSrc, transform.ErrShortDst
+			}
+		}
+	}
+	if (t.printBracket || (t.open > 0 && t.letter != 0)) && !atEOF {
+		// the opening brace is held until we know the content of the group
+		return nDst, nSrc, nil
+	}
+	if !t.write(dst, &nDst) {
+		// not enough space in dst
+		return nDst, nSrc, transform.ErrShortDst
+	}
+	return nDst, nSrc, nil
+}
@@ -1,187 +1,677 @@
-package transformers
-
-import (
-	"bytes"
-	"testing"
-)
-
-func TestToUnicodeAccents_Reset(t *testing.T) {
-	lat := &toUnicodeAccents{letter: 'a', accents: []rune{'`'}}
-	lat.Reset()
-	if lat.letter != 0 {
-		t.Errorf("expected letter=0, got letter=%v", lat.letter)
-	}
-	if len(lat.accents) != 0 {
-		t.Errorf("expected accents=nil, got accents=%v", lat.accents)
-	}
-}
-
-func TestToUnicodeAccents_write(t *testing.T) {
-	data := []struct {
-		t     *toUnicodeAccents // transformer
-		dst   []byte            // destination slice
-		ndst  int               // number of bytes already written to the destination slice
-		exp   []byte            // expected destination slice after writing
-		expn  int               // expected number of bytes written to the destination slice after writing
-		expok bool              // expected return value of the write operation
-	}{
-		{&toUnicodeAccents{letter: 0, accents: []rune{}}, []byte("....."), 0, []byte("....."), 0, true},
-		{&toUnicodeAccents{letter: 'a', accents: []rune{}}, []byte("....."), 0, []byte("a...."), 1, true},
-		{&toUnicodeAccents{letter: 0, accents: []rune{0x300}}, []byte("....."), 0, []byte{0xCC, 0x80, '.', '.', '.'}, 2, true},
-		{&toUnicodeAccents{letter: 0, accents: []rune{0x300, 0x301}}, []byte("....."), 0, []byte{0xCC, 0x81, 0xCC, 0x80, '.'}, 4, true},
-		{&toUnicodeAccents{letter: 0, accents: []rune{0x300, 0x301, 0x302}}, []byte("....."), 0, []byte{0xCC, 0x82, 0xCC, 0x81, 0xCC}, 0, false},
-		{&toUnicodeAccents{letter: 'a', accents: []rune{0x300}}, []byte("....."), 0, []byte{'a', 0xCC, 0x80, '.', '.'}, 3, true},
-		{&toUnicodeAccents{letter: 'a', accents: []rune{0x300, 0x301}}, []byte("....."), 0, []byte{'a', 0xCC, 0x81, 0xCC, 0x80}, 5, true},
-		{&toUnicodeAccents{letter: 'a', accents: []rune{0x300, 0x301, 0x302}}, []byte("....."), 0, []byte{'a', 0xCC, 0x82, 0xCC, 0x81}, 0, false},
-	}
-
-	for i, d := range data {
-		ok := d.t.write(d.dst, &d.ndst)
-		if ok != d.expok {
-			t.Errorf("test %d: expected ok=%v, got ok=%v", i, d.expok, ok)
-		}
-		if !bytes.Equal(d.dst, d.exp) {
-			t.Errorf("test %d: expected dst=%s, got dst=%s", i, d.exp, d.dst)
-		}
-		if d.ndst != d.expn {
-			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, d.ndst)
-		}
-	}
-}
-
-func TestGetSpecial(t *testing.T) {
-	data := []struct {
-		src     string
-		expls   latexSpecial
-		expn    int
-		expMore bool
-	}{
-		{"", latexSpecial{spType: latexSpecialNone}, 0, true},
-		{"K", latexSpecial{spType: latexSpecialNone}, 0, false},
-		{"oups", latexSpecial{spType: latexSpecialNone}, 0, false},
-		{"L", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 1, true},
-		{"L ", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 2, false},
-		{"L a", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 2, false},
-		{"L \\", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 2, false},
-		{"L{}", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 3, false},
-		{"o", latexSpecial{spType: latexSpecialLetter, utf8: 'ø'}, 1, true},
-		{"o ", latexSpecial{spType: latexSpecialLetter, utf8: 'ø'}, 2, false},
-		{"oe", latexSpecial{spType: latexSpecialLetter, utf8: 'œ'}, 2, true},
-		{"oe ", latexSpecial{spType: latexSpecialLetter, utf8: 'œ'}, 3, false},
-		{"oe{}", latexSpecial{spType: latexSpecialLetter, utf8: 'œ'}, 4, false},
-		{"AE", latexSpecial{spType: latexSpecialLetter, utf8: 'Æ'}, 2, true},
-		{"AE ", latexSpecial{spType: latexSpecialLetter, utf8: 'Æ'}, 3, false},
-		{"AE{}", latexSpecial{spType: latexSpecialLetter, utf8: 'Æ'}, 4, false},
-		{"`", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
-		{"` ", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
-		{"`{}", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
-		{"`{", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
-		{"` {", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
-		{"c", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x327}, 1, true},
-		{"c ", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x327}, 2, false},
-		{"c{}", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x327}, 1, false},
-		{"u", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x306}, 1, true},
-		{"u ", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x306}, 2, false},
-		{"u{", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x306}, 1, false},
-		{"up", latexSpecial{spType: latexSpecialNone}, 0, false},
-	}
-
-	for i, d := range data {
-		s, n, more := getSpecial([]byte(d.src))
-		if s.spType != d.expls.spType {
-			t.Errorf("test %d: expected spType=%v, got spType=%v", i, d.expls.spType, s.spType)
-		}
-		if s.utf8 != d.expls.utf8 {
-			t.Errorf("test %d: expected utf8=%v, got utf8=%v", i, d.expls.utf8, s.utf8)
-		}
-		if n != d.expn {
-			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
-		}
-		if more != d.expMore {
-			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
-		}
-	}
-}
-
-func TestGetLetter(t *testing.T) {
-	data := []struct {
-		src     string
-		expl    rune
-		expn    int
-		expMore bool
-	}{
-		{"", 0, 0, true},
-		{"K", 'K', 1, false},
-		{"Kr", 'K', 1, false},
-		{"{", 0, 0, true},
-		{"{K", 0, 0, true},
-		{"{K}", 'K', 3, false},
-		{"{K}r", 'K', 3, false},
-		{"{Kr", 0, 0, false},
-		{"{K ", 0, 0, false},
-		{"{K }", 0, 0, false},
-		{"{\\L}", 'Ł', 4, false},
-		{"{\\L }", 'Ł', 5, false},
-		{"{\\L{}}", 'Ł', 6, false},
-		{"{\\L", 0, 0, true},
-		{"\\L", 0, 0, false},
-	}
-
-	for i, d := range data {
-		s, n, more := getLetter([]byte(d.src))
-		if s != d.expl {
-			t.Errorf("test %d: expected letter=%v, got letter=%v", i, d.expl, s)
-		}
-		if n != d.expn {
-			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
-		}
-		if more != d.expMore {
-			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
-		}
-	}
-}
-
-func TestToUnicodeAccents_Transform(t *testing.T) {
-	data := []struct {
-		lendst int    // the length of the destination slice
-		src    string // source string
-		atEOF  bool   // at EOF
-		exp    []byte // expected destination slice
-	}{
-		{100, "", true, []byte("")},
-		{100, "a", true, []byte("a")},
-		{100, "\\`a", true, []byte{'a', 0xCC, 0x80}},
-		{100, "\\`{a}", true, []byte{'a', 0xCC, 0x80}},
-		{100, "\\`{}a", true, []byte{0xCC, 0x80, 'a'}},
-		{100, "\\'\\`a", true, []byte{'a', 0xCC, 0x80, 0xCC, 0x81}},
-		{100, "\\'\\`", true, []byte{0xCC, 0x80, 0xCC, 0x81}},
-		{100, "\\'\\`{", true, []byte{0xCC, 0x80, 0xCC, 0x81, '{'}},
-		{100, "\\'\\`{}", true, []byte{0xCC, 0x80, 0xCC, 0x81}},
-		{100, "\\'\\`{a", true, []byte{0xCC, 0x80, 0xCC, 0x81, '{', 'a'}},
-		{100, "\\'\\`{a}", true, []byte{'a', 0xCC, 0x80, 0xCC, 0x81}},
-		{100, "\\'\\c", true, []byte{0xCC, 0xA7, 0xCC, 0x81}},
-		{100, "\\'\\c{", true, []byte{0xCC, 0xA7, 0xCC, 0x81, '{'}},
-		{100, "\\'\\c{}", true, []byte{0xCC, 0xA7, 0xCC, 0x81}},
-		{100, "\\'\\c{c", true, []byte{0xCC, 0xA7, 0xCC, 0x81, '{', 'c'}},
-		{100, "\\'\\c{c}", true, []byte{'c', 0xCC, 0xA7, 0xCC, 0x81}},
-		{100, "\\`\\L", true, []byte{0xC5, 0x81, 0xCC, 0x80}},
-		{100, "{\\`\\L}", true, []byte{0xC5, 0x81, 0xCC, 0x80}},
-		{100, "\\up", true, []byte("\\up")},
-	}
-
-	for i, d := range data {
-		lat := &toUnicodeAccents{}
-		dst := make([]byte, d.lendst)
-		nDst, nSrc, err := lat.Transform(dst, []byte(d.src), d.atEOF)
-		if err != nil {
-			t.Errorf("test %d: expected err=nil, got err=%v", i, err)
-		}
-		dst = dst[:nDst]
-		if !bytes.Equal(dst, d.exp) {
-			t.Errorf("test %d: expected dst=%v, got dst=%v", i, d.exp, dst)
-		}
-		if nSrc != len(d.src) {
-			t.Errorf("test %d: expected nSrc=%d, got nSrc=%d", i, len(d.src), nSrc)
-		}
-	}
-}
+package transformers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestToUnicodeAccents_Reset(t *testing.T) {
+	lat := &toUnicodeAccents{letter: 'a', accents: []rune{'`'}}
+	lat.Reset()
+	if lat.letter != 0 {
+		t.Errorf("expected letter=0, got letter=%v", lat.letter)
+	}
+	if len(lat.accents) != 0 {
+		t.Errorf("expected accents=nil, got accents=%v", lat.accents)
+	}
+}
+
+func TestToUnicodeAccents_write(t *testing.T) {
+	data := []struct {
+		t     *toUnicodeAccents // transformer
+		dst   []byte            // destination slice
+		ndst  int               // number of bytes already written to the destination slice
+		exp   []byte            // expected destination slice after writing
+		expn  int               // expected number of bytes written to the destination slice after writing
+		expok bool              // expected return value of the write operation
+	}{
+		{&toUnicodeAccents{letter: 0, accents: []rune{}}, []byte("....."), 0, []byte("....."), 0, true},
+		{&toUnicodeAccents{letter: 'a', accents: []rune{}}, []byte("....."), 0, []byte("a...."), 1, true},
+		{&toUnicodeAccents{letter: 0, accents: []rune{0x300}}, []byte("....."), 0, []byte{0xCC, 0x80, '.', '.', '.'}, 2, true},
+		{&toUnicodeAccents{letter: 0, accents: []rune{0x300, 0x301}}, []byte("....."), 0, []byte{0xCC, 0x81, 0xCC, 0x80, '.'}, 4, true},
+		{&toUnicodeAccents{letter: 0, accents: []rune{0x300, 0x301, 0x302}}, []byte("....."), 0, []byte{0xCC, 0x82, 0xCC, 0x81, 0xCC}, 0, false},
+		{&toUnicodeAccents{letter: 'a', accents: []rune{0x300}}, []byte("....."), 0, []byte{'a', 0xCC, 0x80, '.', '.'}, 3, true},
+		{&toUnicodeAccents{letter: 'a', accents: []rune{0x300, 0x301}}, []byte("....."), 0, []byte{'a', 0xCC, 0x81, 0xCC, 0x80}, 5, true},
+		{&toUnicodeAccents{letter: 'a', accents: []rune{0x300, 0x301, 0x302}}, []byte("....."), 0, []byte{'a', 0xCC, 0x82, 0xCC, 0x81}, 0, false},
+	}
+
+	for i, d := range data {
+		ok := d.t.write(d.dst, &d.ndst)
+		if ok != d.expok {
+			t.Errorf("test %d: expected ok=%v, got ok=%v", i, d.expok, ok)
+		}
+		if !bytes.Equal(d.dst, d.exp) {
+			t.Errorf("test %d: expected dst=%s, got dst=%s", i, d.exp, d.dst)
+		}
+		if d.ndst != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, d.ndst)
+		}
+	}
+}
+
+func TestGetSpecial(t *testing.T) {
+	data := []struct {
+		src     string
+		expls   latexSpecial
+		expn    int
+		expMore bool
+	}{
+		{"", latexSpecial{spType: latexSpecialNone}, 0, true},
+		{"K", latexSpecial{spType: latexSpecialNone}, 0, false},
+		{"oups", latexSpecial{spType: latexSpecialNone}, 0, false},
+		{"L", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 1, true},
+		{"L ", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 2, false},
+		{"L a", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 2, false},
+		{"L \\", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 2, false},
+		{"L{}", latexSpecial{spType: latexSpecialLetter, utf8: 'Ł'}, 3, false},
+		{"o", latexSpecial{spType: latexSpecialLetter, utf8: 'ø'}, 1, true},
+		{"o ", latexSpecial{spType: latexSpecialLetter, utf8: 'ø'}, 2, false},
+		{"oe", latexSpecial{spType: latexSpecialLetter, utf8: 'œ'}, 2, true},
+		{"oe ", latexSpecial{spType: latexSpecialLetter, utf8: 'œ'}, 3, false},
+		{"oe{}", latexSpecial{spType: latexSpecialLetter, utf8: 'œ'}, 4, false},
+		{"AE", latexSpecial{spType: latexSpecialLetter, utf8: 'Æ'}, 2, true},
+		{"AE ", latexSpecial{spType: latexSpecialLetter, utf8: 'Æ'}, 3, false},
+		{"AE{}", latexSpecial{spType: latexSpecialLetter, utf8: 'Æ'}, 4, false},
+		{"`", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
+		{"` ", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
+		{"`{}", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
+		{"`{", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
+		{"` {", latexSpecial{spType: latexSpecialNonLetterAccent, utf8: 0x300}, 1, false},
+		{"c", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x327}, 1, true},
+		{"c ", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x327}, 2, false},
+		{"c{}", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x327}, 1, false},
+		{"u", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x306}, 1, true},
+		{"u ", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x306}, 2, false},
+		{"u{", latexSpecial{spType: latexSpecialLetterAccent, utf8: 0x306}, 1, false},
+		{"up", latexSpecial{spType: latexSpecialNone}, 0, true},
+		{"upx", latexSpecial{spType: latexSpecialNone}, 0, false},
+		{"alpha", latexSpecial{spType: latexSpecialSymbol, utf8: 'α'}, 5, true},
+		{"alpha+", latexSpecial{spType: latexSpecialSymbol, utf8: 'α'}, 5, false},
+		{"alpha x", latexSpecial{spType: latexSpecialSymbol, utf8: 'α'}, 6, false},
+		{"alpha{}x", latexSpecial{spType: latexSpecialSymbol, utf8: 'α'}, 7, false},
+		{"hat{", latexSpecial{spType: latexSpecialMathAccent, utf8: 0x302}, 3, false},
+		{"S", latexSpecial{spType: latexSpecialLetter, utf8: '§'}, 1, true},
+		{"S 3", latexSpecial{spType: latexSpecialLetter, utf8: '§'}, 2, false},
+		{"texteuro{}5", latexSpecial{spType: latexSpecialLetter, utf8: '€'}, 10, false},
+		{"textdegree C", latexSpecial{spType: latexSpecialLetter, utf8: '°'}, 11, false},
+	}
+
+	for i, d := range data {
+		s, n, more := getSpecial([]byte(d.src))
+		if s.spType != d.expls.spType {
+			t.Errorf("test %d: expected spType=%v, got spType=%v", i, d.expls.spType, s.spType)
+		}
+		if s.utf8 != d.expls.utf8 {
+			t.Errorf("test %d: expected utf8=%v, got utf8=%v", i, d.expls.utf8, s.utf8)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGetLetter(t *testing.T) {
+	data := []struct {
+		src     string
+		expl    rune
+		expn    int
+		expMore bool
+	}{
+		{"", 0, 0, true},
+		{"K", 'K', 1, false},
+		{"Kr", 'K', 1, false},
+		{"{", 0, 0, true},
+		{"{K", 0, 0, true},
+		{"{K}", 'K', 3, false},
+		{"{K}r", 'K', 3, false},
+		{"{Kr", 0, 0, false},
+		{"{K ", 0, 0, false},
+		{"{K }", 0, 0, false},
+		{"{\\L}", 'Ł', 4, false},
+		{"{\\L }", 'Ł', 5, false},
+		{"{\\L{}}", 'Ł', 6, false},
+		{"{\\L", 0, 0, true},
+		{"\\L", 0, 0, false},
+		{"1", '1', 1, false},
+		{"ø", 'ø', 2, false},
+		{"\xC3", 0, 0, true},
+		{"{ø}", 'ø', 4, false},
+		{"{ø", 0, 0, true},
+		{"{\xC3", 0, 0, true},
+		{"{1}", '1', 3, false},
+		{".", 0, 0, false},
+		{" ", 0, 0, false},
+		{"\u0301", 0, 0, false},
+	}
+
+	for i, d := range data {
+		s, n, more := getLetter([]byte(d.src))
+		if s != d.expl {
+			t.Errorf("test %d: expected letter=%v, got letter=%v", i, d.expl, s)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestIsNestedAccent(t *testing.T) {
+	data := []struct {
+		src       string
+		expNested bool
+		expMore   bool
+	}{
+		{"", false, true},
+		{"{", false, true},
+		{"{\\", false, true},
+		{"{\\'e}", true, false},
+		{"{\\c{c}}", true, false},
+		{"{\\c", true, false},
+		{"{\\ae}", false, false},
+		{"{\\a", false, true},
+		{"{e}", false, false},
+		{"\\'e", false, false},
+	}
+
+	for i, d := range data {
+		nested, more := isNestedAccent([]byte(d.src))
+		if nested != d.expNested {
+			t.Errorf("test %d: expected nested=%v, got nested=%v", i, d.expNested, nested)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGetLetterPair(t *testing.T) {
+	data := []struct {
+		src     string
+		expl1   rune
+		expl2   rune
+		expn    int
+		expMore bool
+	}{
+		{"", 0, 0, 0, true},
+		{"{", 0, 0, 0, true},
+		{"{t", 0, 0, 0, true},
+		{"{ts", 0, 0, 0, true},
+		{"{ts}", 't', 's', 4, false},
+		{"{ts}x", 't', 's', 4, false},
+		{"{t}", 0, 0, 0, false},
+		{"{tsx}", 0, 0, 0, false},
+		{"ts", 0, 0, 0, false},
+		{"{t\\s}", 0, 0, 0, false},
+		{"{dʒ}", 'd', 'ʒ', 5, false},
+		{"{d\xca", 0, 0, 0, true},
+		{"{\\i u}", 'ı', 'u', 6, false},
+		{"{\\i", 0, 0, 0, true},
+		{"{t\\o}", 't', 'ø', 5, false},
+		{"{\\'e}", 0, 0, 0, false},
+		{"{t }", 0, 0, 0, false},
+	}
+
+	for i, d := range data {
+		l1, l2, n, more := getLetterPair([]byte(d.src))
+		if l1 != d.expl1 || l2 != d.expl2 {
+			t.Errorf("test %d: expected letters=%q%q, got letters=%q%q", i, d.expl1, d.expl2, l1, l2)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestGetConvertedArg(t *testing.T) {
+	data := []struct {
+		src     string
+		exps    string
+		expn    int
+		expMore bool
+	}{
+		{"", "", 0, true},
+		{"{", "", 0, true},
+		{"{NZ", "", 0, true},
+		{"{NZ}", "ℕℤ", 4, false},
+		{"{R}^n", "ℝ", 3, false},
+		{"R^n", "ℝ", 1, false},
+		{"{}", "", 0, false},
+		{"{R^n}", "", 0, false},
+		{"\\alpha", "", 0, false},
+		{"{" + string(make([]byte, maxArg)), "", 0, false},
+	}
+
+	conv := argConverter(latexMathAlphabets["mathbb"])
+	for i, d := range data {
+		s, n, more := getConvertedArg([]byte(d.src), conv)
+		if s != d.exps {
+			t.Errorf("test %d: expected s=%q, got s=%q", i, d.exps, s)
+		}
+		if n != d.expn {
+			t.Errorf("test %d: expected n=%d, got n=%d", i, d.expn, n)
+		}
+		if more != d.expMore {
+			t.Errorf("test %d: expected more=%v, got more=%v", i, d.expMore, more)
+		}
+	}
+}
+
+func TestToUnicodeAccents_Transform(t *testing.T) {
+	data := []struct {
+		lendst int    // the length of the destination slice
+		src    string // source string
+		atEOF  bool   // at EOF
+		exp    []byte // expected destination slice
+	}{
+		{100, "", true, []byte("")},
+		{100, "a", true, []byte("a")},
+		{100, "\\`a", true, []byte{'a', 0xCC, 0x80}},
+		{100, "\\`{a}", true, []byte{'a', 0xCC, 0x80}},
+		{100, "\\`{}a", true, []byte("ˋa")},
+		{100, "\\'\\`a", true, []byte{'a', 0xCC, 0x80, 0xCC, 0x81}},
+		{100, "\\'\\`", true, []byte{0xCC, 0x80, 0xCC, 0x81}},
+		{100, "\\'\\`{", true, []byte{0xCC, 0x80, 0xCC, 0x81, '{'}},
+		{100, "\\'\\`{}", true, []byte{0xC2, 0xA0, 0xCC, 0x80, 0xCC, 0x81}},
+		{100, "\\'\\`{a", true, []byte{0xCC, 0x80, 0xCC, 0x81, '{', 'a'}},
+		{100, "\\'\\`{a}", true, []byte{'a', 0xCC, 0x80, 0xCC, 0x81}},
+		{100, "\\'\\c", true, []byte{0xCC, 0xA7, 0xCC, 0x81}},
+		{100, "\\'\\c{", true, []byte{0xCC, 0xA7, 0xCC, 0x81, '{'}},
+		{100, "\\'\\c{}", true, []byte{0xC2, 0xA0, 0xCC, 0xA7, 0xCC, 0x81}},
+		{100, "\\d{}", true, []byte("\\d{}")},
+		{100, "\\d{}x \\t{}", true, []byte("\\d{}x \\t{}")},
+		{100, "{\\d{}}", true, []byte("{\\d{}}")},
+		{100, "\\'{\\d{}}", true, []byte{0xC2, 0xA0, 0xCC, 0xA3, 0xCC, 0x81}},
+		{100, "\\'\\c{c", true, []byte{0xCC, 0xA7, 0xCC, 0x81, '{', 'c'}},
+		{100, "\\'\\c{c}", true, []byte{'c', 0xCC, 0xA7, 0xCC, 0x81}},
+		{100, "\\`\\L", true, []byte{0xC5, 0x81, 0xCC, 0x80}},
+		{100, "{\\`\\L}", true, []byte{0xC5, 0x81, 0xCC, 0x80}},
+		{100, "\\up", true, []byte("\\up")},
+		{100, "\\texteuro 5", true, []byte("€5")},
+		{100, "\\pounds{}3", true, []byte("£3")},
+		{100, "\\S\\S 3", true, []byte("§§3")},
+		{100, "{\\copyright}", true, []byte("©")},
+		{100, "\\textcommabelow{s}", true, []byte{'s', 0xCC, 0xA6}},
+		{100, "\\textcommabelow t", true, []byte{'t', 0xCC, 0xA6}},
+		{100, "\\h{a}\\G a", true, []byte{'a', 0xCC, 0x89, 'a', 0xCC, 0x8F}},
+		{100, "\\textogonekcentered{o}", true, []byte{'o', 0xCC, 0xA8}},
+		{100, "\\text", true, []byte("\\text")},
+		{100, "\\t{ts}", true, []byte("t\u0361s")},
+		{100, "\\t{t}s \\t o", true, []byte("t\u0361s o\u0361")},
+		{100, "\\textbottomtiebar{ts}", true, []byte("t\u035Cs")},
+		{100, "\\TH{}orr \\th orn", true, []byte("Þorr þorn")},
+		{100, "\\'{\\dh}\\'\\DH", true, []byte("ð\u0301Ð\u0301")},
+		{100, "\\NG\\ij\\SS\\textcrh\\ldot", true, []byte("Ŋĳẞħŀ")},
+		{100, "\\'\\i{} \\v{\\j} \\={\\i}", true, []byte("i\u0301 j\u030C i\u0304")},
+		{100, "\\d{\\i} \\i", true, []byte("ı\u0323 ı")},
+		{100, "\\'{} a\\\"{}b \\c{}", true, []byte("´ a¨b ¸")},
+		{100, "{\\~{}}\\v{}x", true, []byte("˜ˇx")},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{}
+		dst := make([]byte, d.lendst)
+		nDst, nSrc, err := lat.Transform(dst, []byte(d.src), d.atEOF)
+		if err != nil {
+			t.Errorf("test %d: expected err=nil, got err=%v", i, err)
+		}
+		dst = dst[:nDst]
+		if !bytes.Equal(dst, d.exp) {
+			t.Errorf("test %d: expected dst=%v, got dst=%v", i, d.exp, dst)
+		}
+		if nSrc != len(d.src) {
+			t.Errorf("test %d: expected nSrc=%d, got nSrc=%d", i, len(d.src), nSrc)
+		}
+	}
+}
+
+func TestToUnicodeAccents_MathAccents(t *testing.T) {
+	data := []struct {
+		mode texMode // the TeX mode
+		src  string  // source string
+		exp  string  // expected string
+	}{
+		{mathMode, "\\hat{x}", "x\u0302"},
+		{mathMode, "\\hat x", "x\u0302"},
+		{mathMode, "\\bar{a}", "a\u0304"},
+		{mathMode, "\\vec{v}", "v\u20d7"},
+		{mathMode, "\\dot{x}+\\ddot{y}", "x\u0307+y\u0308"},
+		{mathMode, "\\check{c}\\breve{a}", "c\u030ca\u0306"},
+		{mathMode, "\\hat\\bar x", "x\u0304\u0302"},
+		{mathMode, "\\vec{AB}", "\\vec{AB}"},
+		{mathMode, "\\hat\\alpha", "\\hat\\alpha"},
+		{mathMode, "\\hatx", "\\hatx"},
+		{textMode, "\\hat{x}", "\\hat{x}"},
+		{textMode, "\\dot", "\\dot"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{mode: d.mode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToUnicodeAccents_Symbols(t *testing.T) {
+	data := []struct {
+		symbols bool   // convert the symbols
+		src     string // source string
+		exp     string // expected string
+	}{
+		{true, "\\alpha+\\beta", "α+β"},
+		{true, "\\alpha x", "αx"},
+		{true, "\\alpha{}x", "αx"},
+		{true, "x\\le y\\leq z", "x≤y≤z"},
+		{true, "\\varepsilon\\epsilon", "εϵ"},
+		{true, "f\\colon A\\to B", "f\\colon A→B"},
+		{true, "\\alphax", "\\alphax"},
+		{false, "\\alpha", "\\alpha"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{symbols: d.symbols}, mode: mathMode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToUnicodeAccents_MathAlphabets(t *testing.T) {
+	data := []struct {
+		mode texMode // the TeX mode
+		src  string  // source string
+		exp  string  // expected string
+	}{
+		{mathMode, "\\mathbb{R}^n", "ℝ^n"},
+		{mathMode, "\\mathbb R", "ℝ"},
+		{mathMode, "\\mathbb{NZ}", "ℕℤ"},
+		{mathMode, "\\mathcal{L}+\\mathscr{L}", "ℒ+ℒ"},
+		{mathMode, "\\mathfrak{g}", "𝔤"},
+		{mathMode, "\\mathbf{x}_1", "𝐱_1"},
+		{mathMode, "\\mathbb{R^n}", "\\mathbb{R^n}"},
+		{mathMode, "\\mathcal{1}", "\\mathcal{1}"},
+		{textMode, "\\mathbb{R}", "\\mathbb{R}"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{alphabets: true}, mode: d.mode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToUnicodeAccents_Scripts(t *testing.T) {
+	data := []struct {
+		mode texMode // the TeX mode
+		src  string  // source string
+		exp  string  // expected string
+	}{
+		{mathMode, "x^{2}", "x²"},
+		{mathMode, "x^2", "x²"},
+		{mathMode, "x^22", "x²2"},
+		{mathMode, "a_{1}+a_{n+1}", "a₁+aₙ₊₁"},
+		{mathMode, "x^{2}_i", "x²ᵢ"},
+		{mathMode, "x^{q}", "x^{q}"},
+		{mathMode, "x^{2q}", "x^{2q}"},
+		{mathMode, "x^\\alpha", "x^\\alpha"},
+		{mathMode, "x\\_1", "x\\_1"},
+		{mathMode, "1\\textsuperscript{er}", "1ᵉʳ"},
+		{textMode, "x^{2}", "x^{2}"},
+		{textMode, "H\\textsubscript{2}O", "H₂O"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{scripts: true}, mode: d.mode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToUnicodeAccents_Quotes(t *testing.T) {
+	data := []struct {
+		quotes Quotes  // the quotes convention
+		mode   texMode // the TeX mode
+		src    string  // source string
+		exp    string  // expected string
+	}{
+		{EnglishQuotes, textMode, "``Hello''", "“Hello”"},
+		{EnglishQuotes, textMode, "`x' l'angle", "‘x’ l’angle"},
+		{EnglishQuotes, textMode, "<<Salut>>", "«Salut»"},
+		{EnglishQuotes, textMode, "a, b,,c", "a, b„c"},
+		{EnglishQuotes, textMode, "\\`e", "è"},
+		{GermanQuotes, textMode, "\\glqq Text\\grqq{} und \\glq x\\grq", "„Text“ und ‚x‘"},
+		{FrenchQuotes, textMode, "\\guillemotleft\\,Salut\\,\\guillemotright", "« Salut »"},
+		{EnglishQuotes, textMode, "a\\,b", "a\\,b"},
+		{EnglishQuotes, mathMode, "f'(x)<<1", "f'(x)<<1"},
+		{NoQuotes, textMode, "``Hello'' \\glqq", "``Hello'' \\glqq"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{quotes: d.quotes}, mode: d.mode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToUnicodeAccents_Dashes(t *testing.T) {
+	data := []struct {
+		dashes bool    // convert the dashes
+		mode   texMode // the TeX mode
+		src    string  // source string
+		exp    string  // expected string
+	}{
+		{true, textMode, "1--2 a---b c-d", "1–2 a—b c-d"},
+		{true, textMode, "\\textendash{} \\textemdash", "– —"},
+		{true, textMode, "a\\ldots{} b\\dots", "a… b…"},
+		{true, textMode, "x~y a\\,b hy\\-phen", "x\u00a0y a\u202fb hy\u00adphen"},
+		{true, textMode, "\\item[--] [a=--]", "\\item[--] [a=--]"},
+		{true, mathMode, "a--b~c \\ldots", "a--b~c …"},
+		{false, textMode, "a--b~c \\ldots", "a--b~c \\ldots"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{dashes: d.dashes}, mode: d.mode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+	}
+}
+
+func TestToUnicodeAccents_CharCodes(t *testing.T) {
+	data := []struct {
+		codes CharCodes // the character codes policy
+		mode  texMode   // the TeX mode
+		src   string    // source string
+		exp   string    // expected string
+	}{
+		{AllCharCodes, textMode, "\\char\"E9t \\char233 x\\char'351", "ét éxé"},
+		{AllCharCodes, textMode, "\\symbol{\"E9} {\\char\"0436} \\unichar{1078}", "é ж ж"},
+		{AllCharCodes, textMode, "^^e9 ^^^^00e9 ^^^^^^01d49c ^^E9", "é é 𝒜 ^^E9"},
+		{AllCharCodes, textMode, "\\accent\"7F e \\accent127{o}\\accent\"01 \\i", "e\u0308 o\u0308i\u0301"},
+		{AllCharCodes, textMode, "\\char\"5C \\char \\charx \\accent\"99 e \\symbol{\"ZZ}", "\\char\"5C \\char \\charx \\accent\"99 e \\symbol{\"ZZ}"},
+		{AllCharCodes, textMode, "\\u{\\symbol{\"0438}}\\u{^^^^0438}", "и\u0306и\u0306"},
+		{AllCharCodes, mathMode, "x^^e9^2 \\char\"E9", "xé^2 é"},
+		{TextCharCodes, mathMode, "x^^e9^2 \\char\"E9", "x^^e9^2 \\char\"E9"},
+		{NoCharCodes, textMode, "\\char\"E9 ^^e9", "\\char\"E9 ^^e9"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{charCodes: d.codes}, mode: d.mode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		lat = &toUnicodeAccents{options: options{charCodes: d.codes}, mode: d.mode}
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), lat))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+func TestToUnicodeAccents_Shorthands(t *testing.T) {
+	data := []struct {
+		langs []string // the languages whose shorthands are converted
+		mode  texMode  // the TeX mode
+		src   string   // source string
+		exp   string   // expected string
+	}{
+		{defaultShorthandLangs, textMode, "\"a \\selectlanguage{ngerman}Gr\"u\"se, \"`Hallo\"' Zu\"cker \"x",
+			"\"a \\selectlanguage{ngerman}Grüße, „Hallo“ Zucker \"x"},
+		{defaultShorthandLangs, textMode, "\\usepackage[ngerman]{babel}\"a\\selectlanguage{english}\"a",
+			"\\usepackage[ngerman]{babel}ä\\selectlanguage{english}\"a"},
+		{defaultShorthandLangs, textMode, "\\begin{otherlanguage}{dutch}\"e\\begin{otherlanguage*}{swedish}\"e\"o\\end{otherlanguage*}\"e\\end{otherlanguage}\"e",
+			"\\begin{otherlanguage}{dutch}ë\\begin{otherlanguage*}{swedish}\"eö\\end{otherlanguage*}ë\\end{otherlanguage}\"e"},
+		{defaultShorthandLangs, textMode, "\\selectlanguage{ngerman}Auf\"|lage Ge\"-burts\"=tag \"<x\">",
+			"\\selectlanguage{ngerman}Auf\u200Clage Ge\u00ADburts-tag «x»"},
+		{defaultShorthandLangs, textMode, "\\selectlanguage{ngerman}\\'e\"a\\char\"E9",
+			"\\selectlanguage{ngerman}e\u0301äé"},
+		{defaultShorthandLangs, textMode, "\\selectlanguage{dutch}na\"ief \"ij \"i", "\\selectlanguage{dutch}naïef ij ï"},
+		{defaultShorthandLangs, mathMode, "\\selectlanguage{ngerman}\"a", "\\selectlanguage{ngerman}\"a"},
+		{[]string{"dutch"}, textMode, "\\selectlanguage{ngerman}\"a", "\\selectlanguage{ngerman}\"a"},
+		{nil, textMode, "\\selectlanguage{ngerman}\"a", "\\selectlanguage{ngerman}\"a"},
+	}
+
+	for i, d := range data {
+		lat := &toUnicodeAccents{options: options{shorthands: d.langs, charCodes: AllCharCodes}, mode: d.mode}
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// feed the transformer one byte at a time to check the chunk boundaries
+		lat = &toUnicodeAccents{options: options{shorthands: d.langs, charCodes: AllCharCodes}, mode: d.mode}
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), lat))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+func TestToUnicodeAccents_Braces(t *testing.T) {
+	data := []struct {
+		braces  Braces // the braces policy
+		protect bool   // protect the case
+		src     string // source string
+		exp     string // expected string
+	}{
+		{StripAccentGroups, false, "{\\'e} x {\\'e} {\\ss}", "é x é ß"},
+		{StripAccentGroups, false, "Fran{\\c{c}}ois {\\\"{o}}", "François ö"},
+		{StripAccentGroups, false, "{{\\'e}} {\\'et\\'e} {} {Foo}", "{é} {été} {} {Foo}"},
+		{StripAccentGroups, false, "\\emph{\\'e} \\href{a}{\\'e} \\cite[p]{\\'e}", "\\emph{é} \\href{a}{é} \\cite[p]{é}"},
+		{StripAccentGroups, false, "\\foo@bar{\\'e} \\@namedef{x}{\\'e} \\section*{\\'e} \\textbf {\\'e}", "\\foo@bar{é} \\@namedef{x}{é} \\section*{é} \\textbf {é}"},
+		{StripAccentGroups, false, "\\href{a} {\\'e} \\\\ {\\'e} -{\\'e}", "\\href{a} é \\\\ é -é"},
+		{StripAccentGroups, false, "x^{\\'e} \\def\\a#1{\\'e}", "x^{é} \\def\\a#1{é}"},
+		{StripAccentGroups, true, "{\\'E}cole {\\'e}t\\'e", "{É}cole été"},
+		{KeepBraces, false, "{\\'e} x {\\ss}", "{é} x {ß}"},
+	}
+
+	for i, d := range data {
+		lat := transform.Chain(&toUnicodeAccents{options: options{braces: d.braces, protectCase: d.protect}}, norm.NFC)
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// the result should not depend on the chunk boundaries
+		lat.Reset()
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), lat))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}
+
+func TestToUnicodeAccents_Bases(t *testing.T) {
+	data := []struct {
+		src string // source string
+		exp string // expected string
+	}{
+		{"\\'{\\ae} \\'{\\o} \\\"{\\oe} \\~{\\ng}", "ǽ ǿ œ̈ ŋ̃"},
+		{"\\'ø \\'{ø} \\'1 \\={1}", "ǿ ǿ 1́ 1̄"},
+		{"\\'{\\\"{u}} \\'{\\^e} \\=\\k{o}", "ǘ ế ǭ"},
+		{"{\\'{\\\"u}} \\'{\\\"\\i}x", "ǘ ḯx"},
+		{"\\'{\\\"u x}", "{ǘ x}"},
+		{"\\'{\\\"u}\\'e", "ǘé"},
+		{"\\'{ae} \\'{}a", "\u0301{ae} ´a"},
+	}
+
+	for i, d := range data {
+		lat := transform.Chain(&toUnicodeAccents{options: options{braces: StripAccentGroups}}, norm.NFC)
+		got, _, err := transform.String(lat, d.src)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if got != d.exp {
+			t.Errorf("test %d: expected %q, got %q", i, d.exp, got)
+		}
+		// the result should not depend on the chunk boundaries
+		lat.Reset()
+		b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(d.src)), lat))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if string(b) != d.exp {
+			t.Errorf("test %d (one byte): expected %q, got %q", i, d.exp, string(b))
+		}
+	}
+}